@@ -0,0 +1,201 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jmartin127/linear-autolabeler/config"
+	"github.com/jmartin127/linear-autolabeler/linear"
+)
+
+// Action is something done to an issue when it matches a rule
+type Action interface {
+	// Apply performs the action, returning whether the issue was changed
+	Apply(ctx context.Context, e *Event) (bool, error)
+	String() string
+}
+
+// Event is an issue matching a rule, which the rule's actions are applied to in order
+type Event struct {
+	Issue *linear.IssueNode
+	// Vars are substituted into comment templates, e.g. ${sla}
+	Vars map[string]string
+	// Changed is set once one of the actions has changed the issue
+	Changed bool
+	// Applied are the actions which changed the issue, in the order they were applied
+	Applied []Action
+	// Log is where the changes made to the issue are logged, or the standard logger if nil
+	Log *log.Logger
+}
+
+func (e *Event) logf(format string, v ...interface{}) {
+	if e.Log == nil {
+		log.Printf(format, v...)
+		return
+	}
+	e.Log.Printf(format, v...)
+}
+
+// Run applies the actions to the event in order, stopping at the first error
+func Run(ctx context.Context, actions []Action, e *Event) error {
+	for _, a := range actions {
+		changed, err := a.Apply(ctx, e)
+		if err != nil {
+			return fmt.Errorf("%s: %w", a, err)
+		}
+		if changed {
+			e.logf("Ticket: %s, Applied: %s\n", linear.TicketNumber(e.Issue), a)
+			e.Changed = true
+			e.Applied = append(e.Applied, a)
+		}
+	}
+
+	return nil
+}
+
+// Env holds what the actions need in order to be built and applied. IDs of the labels and users, and the workflow
+// states, named in the config are looked up once and cached. Now defaults to time.Now.
+type Env struct {
+	Client   *linear.LinearClient
+	TeamID   string
+	Location *time.Location
+	Now      func() time.Time
+
+	labelIDs map[string]string
+	userIDs  map[string]string
+	states   map[string]linear.State
+}
+
+// Build turns the configured actions into actions which can be applied to issues
+func Build(ctx context.Context, cfgActions []config.Action, env *Env) ([]Action, error) {
+	if env.Now == nil {
+		env.Now = time.Now
+	}
+
+	actions := make([]Action, 0, len(cfgActions))
+	for _, a := range cfgActions {
+		action, err := buildAction(ctx, a, env)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
+
+// ManagedLabels returns the labels added by the actions. These are removed from issues which no longer match the
+// rule.
+func ManagedLabels(actions []Action) []*AddLabel {
+	labels := make([]*AddLabel, 0)
+	for _, a := range actions {
+		if l, ok := a.(*AddLabel); ok {
+			labels = append(labels, l)
+		}
+	}
+	return labels
+}
+
+func buildAction(ctx context.Context, a config.Action, env *Env) (Action, error) {
+	switch {
+	case strings.EqualFold(a.Type, config.ActionTypeAddLabel):
+		labelID, err := env.labelID(ctx, a.Label)
+		if err != nil {
+			return nil, err
+		}
+		return &AddLabel{Client: env.Client, Label: a.Label, LabelID: labelID}, nil
+	case strings.EqualFold(a.Type, config.ActionTypeRemoveLabel):
+		labelID, err := env.labelID(ctx, a.Label)
+		if err != nil {
+			return nil, err
+		}
+		return &RemoveLabel{Client: env.Client, Label: a.Label, LabelID: labelID}, nil
+	case strings.EqualFold(a.Type, config.ActionTypeComment):
+		return &Comment{Client: env.Client, Template: a.Comment}, nil
+	case strings.EqualFold(a.Type, config.ActionTypeSetAssignee):
+		userID, err := env.userID(ctx, a.Assignee)
+		if err != nil {
+			return nil, err
+		}
+		return &SetAssignee{Client: env.Client, Assignee: a.Assignee, UserID: userID}, nil
+	case strings.EqualFold(a.Type, config.ActionTypeSetState):
+		state, err := env.state(ctx, a.State)
+		if err != nil {
+			return nil, err
+		}
+		return &SetState{Client: env.Client, State: a.State, StateID: state.ID, StateType: state.Type}, nil
+	case strings.EqualFold(a.Type, config.ActionTypeBumpPriority):
+		return &BumpPriority{Client: env.Client, Priority: a.Priority}, nil
+	case strings.EqualFold(a.Type, config.ActionTypeAddSubscriber):
+		userID, err := env.userID(ctx, a.Subscriber)
+		if err != nil {
+			return nil, err
+		}
+		return &AddSubscriber{Client: env.Client, Subscriber: a.Subscriber, UserID: userID}, nil
+	case strings.EqualFold(a.Type, config.ActionTypeSetDueDate):
+		return &SetDueDate{Client: env.Client, DueInDays: a.DueInDays, Location: env.Location, Now: env.Now}, nil
+	}
+
+	return nil, fmt.Errorf("unknown action type %q", a.Type)
+}
+
+func (env *Env) labelID(ctx context.Context, name string) (string, error) {
+	if id, ok := env.labelIDs[name]; ok {
+		return id, nil
+	}
+
+	log.Printf("Finding %s label...\n", name)
+	id, err := env.Client.FindLabelIDWithName(ctx, env.TeamID, name)
+	if err != nil {
+		return "", err
+	}
+	if env.labelIDs == nil {
+		env.labelIDs = make(map[string]string)
+	}
+	env.labelIDs[name] = id
+
+	return id, nil
+}
+
+func (env *Env) userID(ctx context.Context, name string) (string, error) {
+	if id, ok := env.userIDs[name]; ok {
+		return id, nil
+	}
+
+	id, err := env.Client.FindUserID(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if env.userIDs == nil {
+		env.userIDs = make(map[string]string)
+	}
+	env.userIDs[name] = id
+
+	return id, nil
+}
+
+// state looks up the team's workflow state with the name, along with its type
+func (env *Env) state(ctx context.Context, name string) (linear.State, error) {
+	if st, ok := env.states[name]; ok {
+		return st, nil
+	}
+
+	states, err := env.Client.GetWorkflowStates(ctx, env.TeamID)
+	if err != nil {
+		return linear.State{}, err
+	}
+	for _, st := range states {
+		if st.Name == name {
+			if env.states == nil {
+				env.states = make(map[string]linear.State)
+			}
+			env.states[name] = st
+			return st, nil
+		}
+	}
+
+	return linear.State{}, fmt.Errorf("cannot find workflow state with name %s: %w", name, linear.ErrNotFound)
+}
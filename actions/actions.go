@@ -0,0 +1,189 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmartin127/linear-autolabeler/linear"
+)
+
+// AddLabel adds the label to the issue
+type AddLabel struct {
+	Client  *linear.LinearClient
+	Label   string
+	LabelID string
+}
+
+func (a *AddLabel) Apply(ctx context.Context, e *Event) (bool, error) {
+	return a.Client.AddLabelToTicket(ctx, e.Issue, linear.IssueLabelNode{ID: a.LabelID, Name: a.Label})
+}
+
+func (a *AddLabel) String() string {
+	return fmt.Sprintf("add label %s", a.Label)
+}
+
+// RemoveLabel removes the label from the issue
+type RemoveLabel struct {
+	Client  *linear.LinearClient
+	Label   string
+	LabelID string
+}
+
+func (a *RemoveLabel) Apply(ctx context.Context, e *Event) (bool, error) {
+	return a.Client.RemoveLabelFromTicket(ctx, e.Issue, a.LabelID)
+}
+
+func (a *RemoveLabel) String() string {
+	return fmt.Sprintf("remove label %s", a.Label)
+}
+
+// Comment posts a comment to the issue, filling in the event's vars within the template. So that the same comment is
+// not posted on every run, it is only posted once an earlier action has changed the issue.
+type Comment struct {
+	Client   *linear.LinearClient
+	Template string
+}
+
+func (a *Comment) Apply(ctx context.Context, e *Event) (bool, error) {
+	if !e.Changed {
+		return false, nil
+	}
+
+	comment := RenderComment(a.Template, e.Vars)
+	e.logf("Ticket: %s, Adding Comment: %s\n", linear.TicketNumber(e.Issue), comment)
+	if err := a.Client.AddCommentToTicket(ctx, e.Issue, comment); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (a *Comment) String() string {
+	return "comment"
+}
+
+// SetAssignee assigns the issue to the user
+type SetAssignee struct {
+	Client   *linear.LinearClient
+	Assignee string
+	UserID   string
+}
+
+func (a *SetAssignee) Apply(ctx context.Context, e *Event) (bool, error) {
+	if e.Issue.Assignee.ID == a.UserID {
+		return false, nil
+	}
+
+	if err := a.Client.SetAssignee(ctx, linear.TicketNumber(e.Issue), a.UserID); err != nil {
+		return false, err
+	}
+	e.Issue.Assignee = linear.Assignee{ID: a.UserID, Name: a.Assignee}
+
+	return true, nil
+}
+
+func (a *SetAssignee) String() string {
+	return fmt.Sprintf("set assignee %s", a.Assignee)
+}
+
+// SetState moves the issue to the workflow state
+type SetState struct {
+	Client    *linear.LinearClient
+	State     string
+	StateID   string
+	StateType string
+}
+
+func (a *SetState) Apply(ctx context.Context, e *Event) (bool, error) {
+	if e.Issue.State.ID == a.StateID {
+		return false, nil
+	}
+
+	if err := a.Client.SetState(ctx, linear.TicketNumber(e.Issue), a.StateID); err != nil {
+		return false, err
+	}
+	e.Issue.State = linear.State{ID: a.StateID, Name: a.State, Type: a.StateType}
+
+	return true, nil
+}
+
+func (a *SetState) String() string {
+	return fmt.Sprintf("set state %s", a.State)
+}
+
+// BumpPriority raises the priority of the issue, leaving issues which already have a higher priority alone. Linear
+// priorities run from 1 (urgent) to 4 (low), with 0 meaning no priority.
+type BumpPriority struct {
+	Client   *linear.LinearClient
+	Priority int
+}
+
+func (a *BumpPriority) Apply(ctx context.Context, e *Event) (bool, error) {
+	if e.Issue.Priority != 0 && e.Issue.Priority <= a.Priority {
+		return false, nil
+	}
+
+	if err := a.Client.SetPriority(ctx, linear.TicketNumber(e.Issue), a.Priority); err != nil {
+		return false, err
+	}
+	e.Issue.Priority = a.Priority
+
+	return true, nil
+}
+
+func (a *BumpPriority) String() string {
+	return fmt.Sprintf("bump priority to %d", a.Priority)
+}
+
+// AddSubscriber subscribes the user to the issue
+type AddSubscriber struct {
+	Client     *linear.LinearClient
+	Subscriber string
+	UserID     string
+}
+
+func (a *AddSubscriber) Apply(ctx context.Context, e *Event) (bool, error) {
+	return a.Client.AddSubscriberToTicket(ctx, e.Issue, a.UserID)
+}
+
+func (a *AddSubscriber) String() string {
+	return fmt.Sprintf("add subscriber %s", a.Subscriber)
+}
+
+// SetDueDate sets the due date of the issue to the given number of days from now. Issues which already have a due
+// date keep it.
+type SetDueDate struct {
+	Client    *linear.LinearClient
+	DueInDays int
+	Location  *time.Location
+	Now       func() time.Time
+}
+
+func (a *SetDueDate) Apply(ctx context.Context, e *Event) (bool, error) {
+	if e.Issue.DueDate != "" {
+		return false, nil
+	}
+
+	dueDate := a.Now().In(a.Location).AddDate(0, 0, a.DueInDays).Format("2006-01-02")
+	if err := a.Client.SetDueDate(ctx, linear.TicketNumber(e.Issue), dueDate); err != nil {
+		return false, err
+	}
+	e.Issue.DueDate = dueDate
+
+	return true, nil
+}
+
+func (a *SetDueDate) String() string {
+	return fmt.Sprintf("set due date in %d days", a.DueInDays)
+}
+
+// RenderComment fills in the ${name} placeholders of a comment template
+func RenderComment(template string, vars map[string]string) string {
+	oldnew := make([]string, 0, len(vars)*2)
+	for k, v := range vars {
+		oldnew = append(oldnew, "${"+k+"}", v)
+	}
+	return strings.NewReplacer(oldnew...).Replace(template)
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jmartin127/linear-autolabeler/config"
+	"github.com/jmartin127/linear-autolabeler/metrics"
+	"github.com/jmartin127/linear-autolabeler/runner"
+	"github.com/jmartin127/linear-autolabeler/snapshot"
+)
+
+// Flags of the run and plan commands
+var (
+	stateFile  string
+	dryRun     bool
+	planFormat string
+	planFile   string
+)
+
+func addPlanFlags(fs *flag.FlagSet) {
+	fs.StringVar(&stateFile, "state-file", "", "Path to the local state of previous runs, overriding the stateFile in the config")
+	fs.StringVar(&planFormat, "plan-format", "text", "Format of the dry run plan, text or json")
+	fs.StringVar(&planFile, "plan-file", "", "Path to write the dry run plan to, instead of stdout")
+}
+
+var runCommand = &command{
+	name:    "run",
+	summary: "Run the rules against every team in the config, changing the tickets they match",
+	flags: func(fs *flag.FlagSet) {
+		addPlanFlags(fs)
+		fs.BoolVar(&dryRun, "dry-run", false, "Evaluate the rules without changing anything in Linear, and print a plan of the changes")
+	},
+	run: func(ctx context.Context, args []string) error {
+		return runTeams(ctx)
+	},
+}
+
+var planCommand = &command{
+	name:    "plan",
+	summary: "Evaluate the rules without changing anything in Linear, and print the changes a run would make",
+	flags:   addPlanFlags,
+	run: func(ctx context.Context, args []string) error {
+		dryRun = true
+		return runTeams(ctx)
+	},
+}
+
+var metricsCommand = &command{
+	name:    "metrics",
+	summary: "Print how long the team's tickets spent in each state, and how many were created each week",
+	run: func(ctx context.Context, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		// the config has already been narrowed down to the --team, wherever it is listed
+		teams := configTeams(cfg)
+		switch {
+		case len(teams) == 0:
+			return fmt.Errorf("no team was provided, set it in the config or with --team")
+		case len(teams) > 1:
+			return fmt.Errorf("the config has %d teams, choose one with --team", len(teams))
+		case teams[0].err != nil:
+			return teams[0].err
+		}
+
+		t := teams[0]
+		team, err := t.lc.FindTeam(ctx, t.team.Team)
+		if err != nil {
+			return err
+		}
+		return metrics.Run(ctx, t.lc, team.ID, cfg.PageSize)
+	},
+}
+
+var explainCommand = &command{
+	name:    "explain",
+	args:    "<TICKET-123>",
+	nargs:   1,
+	summary: "Show why each rule does or does not match the ticket, and what a run would do to it",
+	run: func(ctx context.Context, args []string) error {
+		ticketNumber := strings.ToUpper(args[0])
+		dash := strings.LastIndex(ticketNumber, "-")
+		if dash < 1 {
+			return fmt.Errorf("%q is not a ticket number such as INT-123", args[0])
+		}
+		key := ticketNumber[:dash]
+
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+
+		// find the team in the config which the ticket belongs to, skipping any which cannot be looked up
+		for _, t := range configTeams(cfg) {
+			if t.err != nil {
+				continue
+			}
+			team, err := t.lc.FindTeam(ctx, t.team.Team)
+			if err != nil {
+				log.Printf("Skipping team %s (workspace %s): %v\n", t.team.Team, t.workspace, err)
+				continue
+			}
+			if !strings.EqualFold(team.Key, key) {
+				continue
+			}
+
+			explanation, err := runner.ExplainTicket(ctx, t.lc, t.team, ticketNumber, runner.Options{})
+			if err != nil {
+				return err
+			}
+			return explanation.WriteText(os.Stdout)
+		}
+
+		return fmt.Errorf("no team with key %s was found in the config", key)
+	},
+}
+
+var offline bool
+
+var validateConfigCommand = &command{
+	name:    "validate-config",
+	summary: "Check the config, and that the states and labels it names exist in each team",
+	flags: func(fs *flag.FlagSet) {
+		fs.BoolVar(&offline, "offline", false, "Only check the config itself, without checking it against Linear")
+	},
+	run: func(ctx context.Context, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("The config %s is valid\n", configPath)
+		if offline {
+			return nil
+		}
+
+		var failed int
+		teams := configTeams(cfg)
+		for _, t := range teams {
+			err := t.err
+			if err == nil {
+				err = runner.CheckTeam(ctx, t.lc, t.team)
+			}
+			if err != nil {
+				fmt.Printf("Team %s (workspace %s): %v\n", t.team.Team, t.workspace, err)
+				failed++
+				continue
+			}
+			fmt.Printf("Team %s (workspace %s): ok\n", t.team.Team, t.workspace)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d teams do not match the config", failed, len(teams))
+		}
+
+		return nil
+	},
+}
+
+var force bool
+
+var initCommand = &command{
+	name:    "init",
+	summary: "Write an example config to get started with",
+	flags: func(fs *flag.FlagSet) {
+		fs.BoolVar(&force, "force", false, "Overwrite the config if it already exists")
+	},
+	run: func(ctx context.Context, args []string) error {
+		if _, err := os.Stat(configPath); err == nil && !force {
+			return fmt.Errorf("%s already exists, pass --force to overwrite it", configPath)
+		}
+
+		team := teamName
+		if team == "" {
+			team = "ENG"
+		}
+		if err := ioutil.WriteFile(configPath, []byte(fmt.Sprintf(exampleConfig, team)), 0644); err != nil {
+			return err
+		}
+		if _, err := config.Load(configPath, ""); err != nil {
+			return err
+		}
+
+		fmt.Printf("Wrote %s, check it with: %s validate-config --config %s\n", configPath, binaryName, configPath)
+		return nil
+	},
+}
+
+// exampleConfig is written by init, with the team filled in
+const exampleConfig = `team: %q
+timeZone: "America/Denver"
+ignoreIssueStateTypes:
+  - "completed"
+  - "canceled"
+labels:
+  - name: "ExceedsSLA"
+    color: "#eb5757"
+    description: "The ticket has been in its current state for longer than its SLA"
+job:
+  - name: "SLA: Taking too long to start tickets"
+    filter:
+      - type: SLA
+        currentStateType: "unstarted"
+        longerThan: 16h
+    action:
+      label: "ExceedsSLA"
+      comment: "This ticket has not been started, and exceeds the SLA by ${slaExceeding}.  The SLA is ${sla} (in business hours)."
+`
+
+// runTeams runs, or in a dry run plans, every team in the config
+func runTeams(ctx context.Context) error {
+	if planFormat != "text" && planFormat != "json" {
+		return fmt.Errorf("unknown plan format %q, expected text or json", planFormat)
+	}
+
+	log.Println("Starting...")
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if stateFile != "" {
+		cfg.StateFile = stateFile
+	}
+
+	// only issues updated since the previous run are read when there is local state
+	opts := runner.Options{PageSize: cfg.PageSize, FullSyncInterval: cfg.FullSyncInterval, BatchSize: cfg.MutationBatchSize, Workers: cfg.Workers, DryRun: dryRun}
+	if cfg.StateFile != "" {
+		if opts.State, err = snapshot.Load(cfg.StateFile); err != nil {
+			return err
+		}
+	}
+
+	// run each team of each workspace, carrying on to the next team if one fails
+	results := make([]runner.Result, 0)
+	for _, t := range configTeams(cfg) {
+		if t.err != nil {
+			results = append(results, runner.Result{Workspace: t.workspace, Team: t.team.Team, Err: t.err})
+			continue
+		}
+		results = append(results, runner.RunTeam(ctx, t.lc, t.workspace, t.team, opts))
+	}
+
+	if dryRun {
+		if err := writePlan(results); err != nil {
+			return err
+		}
+	}
+
+	// teams which failed, including those whose last mutations could not be sent, keep their previous watermark, so
+	// their updates are read again by the next run. A dry run leaves the state as it was, as none of its changes were
+	// made.
+	if opts.State != nil && !dryRun {
+		if err := opts.State.Save(cfg.StateFile); err != nil {
+			return err
+		}
+	}
+
+	if failed := printResults(results); failed > 0 {
+		return fmt.Errorf("%d of %d teams failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// printResults prints the results of each team, and returns how many failed. Like the progress of the run, they go to
+// stderr, so that stdout only holds the plan.
+func printResults(results []runner.Result) int {
+	var failed int
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "WORKSPACE\tTEAM\tISSUES\tMATCHED\tSKIPPED\tERROR")
+	for _, r := range results {
+		errText := "-"
+		if r.Err != nil {
+			errText = r.Err.Error()
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%s\n", r.Workspace, r.Team, r.Issues, r.Matched, r.Skipped, errText)
+	}
+	w.Flush()
+
+	return failed
+}
+
+// writePlan writes what the dry run would have done, to the plan file or stdout
+func writePlan(results []runner.Result) error {
+	write := runner.WritePlanText
+	if planFormat == "json" {
+		write = runner.WritePlanJSON
+	}
+	if planFile == "" {
+		return write(os.Stdout, results)
+	}
+
+	f, err := os.Create(planFile)
+	if err != nil {
+		return err
+	}
+	if err := write(f, results); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,570 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jmartin127/linear-autolabeler/linear"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultPageSize         = 50
+	defaultFullSyncInterval = 24 * time.Hour
+	defaultMutationBatch    = 20
+	defaultWorkers          = 4
+)
+
+// Action types supported within a job. Types are matched case-insensitively.
+const (
+	ActionTypeAddLabel      = "AddLabel"
+	ActionTypeRemoveLabel   = "RemoveLabel"
+	ActionTypeComment       = "Comment"
+	ActionTypeSetAssignee   = "SetAssignee"
+	ActionTypeSetState      = "SetState"
+	ActionTypeBumpPriority  = "BumpPriority"
+	ActionTypeAddSubscriber = "AddSubscriber"
+	ActionTypeSetDueDate    = "SetDueDate"
+)
+
+// Filter types supported within a job. Types are matched case-insensitively.
+const (
+	FilterTypeSLA          = "SLA"
+	FilterTypeLastComment  = "LastComment"
+	FilterTypeHasLabel     = "HasLabel"
+	FilterTypeAssigneeIs   = "AssigneeIs"
+	FilterTypeStateIs      = "StateIs"
+	FilterTypeTitleMatches = "TitleMatches"
+	FilterTypeAll          = "all"
+	FilterTypeAny          = "any"
+	FilterTypeNot          = "not"
+)
+
+// Config is the YAML config. A single team can be configured at the top level, and further teams listed under
+// teams, all of which use the token given on the command line. Teams in other workspaces are listed under workspaces,
+// each with its own token. When a state file is set, runs only read the issues updated since the previous run, and
+// read every active issue again once the full sync interval has passed. Label changes and comments are sent
+// mutationBatchSize at a time, with 1 sending each on its own. Each team's issues are processed by up to workers at
+// once, and up to workers batches of mutations are sent at once.
+type Config struct {
+	TeamConfig        `yaml:",inline"`
+	PageSize          int           `yaml:"pageSize"`
+	StateFile         string        `yaml:"stateFile"`
+	FullSyncInterval  time.Duration `yaml:"fullSyncInterval"`
+	MutationBatchSize int           `yaml:"mutationBatchSize"`
+	Workers           int           `yaml:"workers"`
+	Teams             []TeamConfig  `yaml:"teams"`
+	Workspaces        []Workspace   `yaml:"workspaces"`
+}
+
+// TeamConfig is the config of a single team. The team can be given as its name, key, or ID. Issues are ignored if
+// their state is named in IgnoreIssueStates, or its type is in IgnoreIssueStateTypes. Comments by the BotUserIDs are
+// ignored along with the auto-labeler's own.
+type TeamConfig struct {
+	Team                  string   `yaml:"team"`
+	TimeZone              string   `yaml:"timeZone"`
+	IgnoreIssueStates     []string `yaml:"ignoreIssueStates"`
+	IgnoreIssueStateTypes []string `yaml:"ignoreIssueStateTypes"`
+	Labels                []Label  `yaml:"labels"`
+	BotUserIDs            []string `yaml:"botUserIds"`
+	Jobs                  []Job    `yaml:"job"`
+}
+
+// Label is a label used by the team's rules, which is created before the run if the team does not have it. The parent
+// is the name of the label group it belongs to.
+type Label struct {
+	Name        string `yaml:"name"`
+	Color       string `yaml:"color"`
+	Description string `yaml:"description"`
+	Parent      string `yaml:"parent"`
+}
+
+// Workspace is a Linear workspace, whose token is read from the TokenEnv environment variable
+type Workspace struct {
+	Name     string       `yaml:"name"`
+	TokenEnv string       `yaml:"tokenEnv"`
+	Teams    []TeamConfig `yaml:"teams"`
+}
+
+// DefaultWorkspace is the name of the workspace accessed with the token given on the command line
+const DefaultWorkspace = "default"
+
+// Job is a set of filters, and the actions to take when an issue matches them. The single action is a shorthand for
+// adding a label and a comment, and is run before the ordered list of actions.
+type Job struct {
+	Name    string   `yaml:"name"`
+	Filter  []Filter `yaml:"filter"`
+	Action  Action   `yaml:"action"`
+	Actions []Action `yaml:"actions"`
+}
+
+// Filter is a single filter within a job. The all, any and not types combine the nested filters, while the other
+// types use only the fields relevant to them. States can be given by name, by type, or both.
+type Filter struct {
+	Type             string        `yaml:"type"`
+	CurrentState     string        `yaml:"currentState"`
+	CurrentStateType string        `yaml:"currentStateType"`
+	EnteredState     string        `yaml:"enteredState"`
+	EnteredStateType string        `yaml:"enteredStateType"`
+	LongerThan       time.Duration `yaml:"longerThan"`
+	Label            string        `yaml:"label"`
+	Assignee         string        `yaml:"assignee"`
+	State            string        `yaml:"state"`
+	StateType        string        `yaml:"stateType"`
+	Pattern          string        `yaml:"pattern"`
+	Filter           []Filter      `yaml:"filter"`
+}
+
+// Action is a single action within a job, using only the fields relevant to its type
+type Action struct {
+	Type       string `yaml:"type"`
+	Label      string `yaml:"label"`
+	Comment    string `yaml:"comment"`
+	Assignee   string `yaml:"assignee"`
+	State      string `yaml:"state"`
+	Priority   int    `yaml:"priority"`
+	Subscriber string `yaml:"subscriber"`
+	DueInDays  int    `yaml:"dueInDays"`
+}
+
+// ActionList returns the ordered actions of the job, expanding the label and comment shorthand
+func (j *Job) ActionList() []Action {
+	actions := make([]Action, 0, len(j.Actions)+2)
+	if j.Action.Type != "" {
+		actions = append(actions, j.Action)
+	} else {
+		if j.Action.Label != "" {
+			actions = append(actions, Action{Type: ActionTypeAddLabel, Label: j.Action.Label})
+		}
+		if j.Action.Comment != "" {
+			actions = append(actions, Action{Type: ActionTypeComment, Comment: j.Action.Comment})
+		}
+	}
+
+	return append(actions, j.Actions...)
+}
+
+// Load reads the YAML config at the given path, applies defaults, and validates it. If team is set, as by the --team
+// flag, only that team is loaded; see selectTeam.
+func Load(path string, team string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing config %s: %w", path, err)
+	}
+
+	if c.PageSize == 0 {
+		c.PageSize = defaultPageSize
+	}
+	if c.FullSyncInterval == 0 {
+		c.FullSyncInterval = defaultFullSyncInterval
+	}
+	if c.MutationBatchSize == 0 {
+		c.MutationBatchSize = defaultMutationBatch
+	}
+	if c.Workers == 0 {
+		c.Workers = defaultWorkers
+	}
+
+	if team != "" {
+		if err := c.selectTeam(team); err != nil {
+			return nil, fmt.Errorf("invalid config %s: %w", path, err)
+		}
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+
+	return &c, nil
+}
+
+// selectTeam narrows the config down to the given team. When only the top level team is configured, the team replaces
+// it. Otherwise the teams of every workspace with that name are kept and the rest dropped, and if none has it, a top
+// level team with jobs but no name of its own takes it.
+func (c *Config) selectTeam(team string) error {
+	if len(c.Teams) == 0 && len(c.Workspaces) == 0 {
+		c.Team = team
+		return nil
+	}
+
+	c.Teams = teamsNamed(c.Teams, team)
+	matched := len(c.Teams) > 0
+	workspaces := make([]Workspace, 0, len(c.Workspaces))
+	for _, w := range c.Workspaces {
+		if w.Teams = teamsNamed(w.Teams, team); len(w.Teams) > 0 {
+			workspaces = append(workspaces, w)
+			matched = true
+		}
+	}
+	c.Workspaces = workspaces
+
+	switch {
+	case strings.EqualFold(c.Team, team):
+	case matched:
+		// the top level team is not listed by AllWorkspaces once it has neither a name nor jobs
+		c.Team = ""
+		c.Jobs = nil
+	case c.Team == "" && len(c.Jobs) > 0:
+		c.Team = team
+	default:
+		return fmt.Errorf("team %q is not in the config", team)
+	}
+
+	return nil
+}
+
+// teamsNamed returns the teams with the given name
+func teamsNamed(teams []TeamConfig, name string) []TeamConfig {
+	named := make([]TeamConfig, 0)
+	for _, t := range teams {
+		if strings.EqualFold(t.Team, name) {
+			named = append(named, t)
+		}
+	}
+	return named
+}
+
+// AllWorkspaces returns every workspace to process, starting with the default workspace which holds the top level team
+// and teams. Teams inherit the top level timeZone, ignoreIssueStates, ignoreIssueStateTypes, labels and botUserIds
+// unless they set their own.
+func (c *Config) AllWorkspaces() []Workspace {
+	defaultWorkspace := Workspace{Name: DefaultWorkspace}
+	if c.Team != "" || len(c.Jobs) > 0 {
+		defaultWorkspace.Teams = append(defaultWorkspace.Teams, c.TeamConfig)
+	}
+	defaultWorkspace.Teams = append(defaultWorkspace.Teams, c.Teams...)
+
+	workspaces := append([]Workspace{defaultWorkspace}, c.Workspaces...)
+	for i := range workspaces {
+		teams := make([]TeamConfig, 0, len(workspaces[i].Teams))
+		for _, t := range workspaces[i].Teams {
+			if t.TimeZone == "" {
+				t.TimeZone = c.TimeZone
+			}
+			if t.IgnoreIssueStates == nil {
+				t.IgnoreIssueStates = c.IgnoreIssueStates
+			}
+			if t.IgnoreIssueStateTypes == nil {
+				t.IgnoreIssueStateTypes = c.IgnoreIssueStateTypes
+			}
+			if t.Labels == nil {
+				t.Labels = c.Labels
+			}
+			if t.BotUserIDs == nil {
+				t.BotUserIDs = c.BotUserIDs
+			}
+			teams = append(teams, t)
+		}
+		workspaces[i].Teams = teams
+	}
+
+	return workspaces
+}
+
+// ShouldIgnoreState returns true if issues in the given state should not be processed
+func (c *TeamConfig) ShouldIgnoreState(state linear.State) bool {
+	for _, ignoredState := range c.IgnoreIssueStates {
+		if state.Name == ignoredState {
+			return true
+		}
+	}
+	for _, ignoredType := range c.IgnoreIssueStateTypes {
+		if strings.EqualFold(state.Type, ignoredType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Config) validate() error {
+	if c.MutationBatchSize < 1 {
+		return fmt.Errorf("mutationBatchSize must be at least 1")
+	}
+	if c.Workers < 1 {
+		return fmt.Errorf("workers must be at least 1")
+	}
+
+	for i, w := range c.Workspaces {
+		if w.Name == "" || w.Name == DefaultWorkspace {
+			return fmt.Errorf("workspace %d: a name other than %q is required", i, DefaultWorkspace)
+		}
+		if w.TokenEnv == "" {
+			return fmt.Errorf("workspace %q: tokenEnv is required", w.Name)
+		}
+	}
+
+	for _, w := range c.AllWorkspaces() {
+		for i, t := range w.Teams {
+			// the top level team, which is listed first when it has jobs, may be given on the command line instead
+			topLevel := w.Name == DefaultWorkspace && i == 0 && c.Team == "" && len(c.Jobs) > 0
+			if t.Team == "" && !topLevel {
+				return fmt.Errorf("workspace %q, team %d: team is required", w.Name, i)
+			}
+			if err := t.validate(); err != nil {
+				return fmt.Errorf("workspace %q, team %q: %w", w.Name, t.Team, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t *TeamConfig) validate() error {
+	if t.TimeZone == "" {
+		return fmt.Errorf("timeZone is required")
+	}
+	for _, stateType := range t.IgnoreIssueStateTypes {
+		if err := validateStateType("ignoreIssueStateTypes", stateType); err != nil {
+			return err
+		}
+	}
+	if err := validateLabels(t.Labels); err != nil {
+		return err
+	}
+
+	for i, j := range t.Jobs {
+		if j.Name == "" {
+			return fmt.Errorf("job %d: name is required", i)
+		}
+		if len(j.Filter) == 0 {
+			return fmt.Errorf("job %q: at least one filter is required", j.Name)
+		}
+		for _, f := range j.Filter {
+			if err := validateFilter(f); err != nil {
+				return fmt.Errorf("job %q: %w", j.Name, err)
+			}
+		}
+		actions := j.ActionList()
+		if len(actions) == 0 {
+			return fmt.Errorf("job %q: at least one action is required", j.Name)
+		}
+		for _, a := range actions {
+			if err := validateAction(a); err != nil {
+				return fmt.Errorf("job %q: %w", j.Name, err)
+			}
+		}
+		// comments are only posted once an earlier action changes the issue, so they are not repeated on every run
+		if strings.EqualFold(actions[0].Type, ActionTypeComment) {
+			return fmt.Errorf("job %q: a comment must follow an action which changes the issue", j.Name)
+		}
+	}
+
+	return nil
+}
+
+func validateFilter(f Filter) error {
+	switch {
+	case strings.EqualFold(f.Type, FilterTypeSLA):
+		if f.CurrentState == "" && f.CurrentStateType == "" {
+			return fmt.Errorf("%s filter requires currentState or currentStateType", f.Type)
+		}
+		if err := validateStateType(f.Type+" filter currentStateType", f.CurrentStateType); err != nil {
+			return err
+		}
+		if err := validateStateType(f.Type+" filter enteredStateType", f.EnteredStateType); err != nil {
+			return err
+		}
+		if f.LongerThan <= 0 {
+			return fmt.Errorf("%s filter requires a positive longerThan", f.Type)
+		}
+	case strings.EqualFold(f.Type, FilterTypeLastComment):
+		if f.LongerThan <= 0 {
+			return fmt.Errorf("%s filter requires a positive longerThan", f.Type)
+		}
+	case strings.EqualFold(f.Type, FilterTypeHasLabel):
+		if f.Label == "" {
+			return fmt.Errorf("%s filter requires label", f.Type)
+		}
+	case strings.EqualFold(f.Type, FilterTypeAssigneeIs):
+	case strings.EqualFold(f.Type, FilterTypeStateIs):
+		if f.State == "" && f.StateType == "" {
+			return fmt.Errorf("%s filter requires state or stateType", f.Type)
+		}
+		if err := validateStateType(f.Type+" filter stateType", f.StateType); err != nil {
+			return err
+		}
+	case strings.EqualFold(f.Type, FilterTypeTitleMatches):
+		if _, err := regexp.Compile(f.Pattern); err != nil {
+			return fmt.Errorf("%s filter has an invalid pattern: %w", f.Type, err)
+		}
+	case strings.EqualFold(f.Type, FilterTypeAll), strings.EqualFold(f.Type, FilterTypeAny):
+		if len(f.Filter) == 0 {
+			return fmt.Errorf("%s filter requires at least one nested filter", f.Type)
+		}
+	case strings.EqualFold(f.Type, FilterTypeNot):
+		if len(f.Filter) != 1 {
+			return fmt.Errorf("%s filter requires exactly one nested filter", f.Type)
+		}
+	default:
+		return fmt.Errorf("unknown filter type %q", f.Type)
+	}
+
+	for _, nested := range f.Filter {
+		if err := validateFilter(nested); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+func validateLabels(labels []Label) error {
+	names := make(map[string]bool, len(labels))
+	for i, l := range labels {
+		if l.Name == "" {
+			return fmt.Errorf("label %d: name is required", i)
+		}
+		if names[l.Name] {
+			return fmt.Errorf("label %q is declared more than once", l.Name)
+		}
+		names[l.Name] = true
+		if l.Color != "" && !labelColorPattern.MatchString(l.Color) {
+			return fmt.Errorf("label %q: color %q is not a hex color such as #eb5757", l.Name, l.Color)
+		}
+		if l.Parent == l.Name {
+			return fmt.Errorf("label %q cannot be its own parent", l.Name)
+		}
+	}
+
+	// Linear does not nest label groups
+	parents := make(map[string]string, len(labels))
+	for _, l := range labels {
+		parents[l.Name] = l.Parent
+	}
+	for _, l := range labels {
+		if l.Parent != "" && parents[l.Parent] != "" {
+			return fmt.Errorf("label %q: parent %q cannot itself have a parent", l.Name, l.Parent)
+		}
+	}
+
+	return nil
+}
+
+// validateStateType checks the state type, if one is given, is one of Linear's workflow state types
+func validateStateType(field, stateType string) error {
+	if stateType == "" {
+		return nil
+	}
+	for _, t := range linear.StateTypes {
+		if strings.EqualFold(stateType, t) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s %q is not one of %s", field, stateType, strings.Join(linear.StateTypes, ", "))
+}
+
+func validateAction(a Action) error {
+	switch {
+	case strings.EqualFold(a.Type, ActionTypeAddLabel), strings.EqualFold(a.Type, ActionTypeRemoveLabel):
+		if a.Label == "" {
+			return fmt.Errorf("%s action requires label", a.Type)
+		}
+	case strings.EqualFold(a.Type, ActionTypeComment):
+		if a.Comment == "" {
+			return fmt.Errorf("%s action requires comment", a.Type)
+		}
+	case strings.EqualFold(a.Type, ActionTypeSetAssignee):
+		if a.Assignee == "" {
+			return fmt.Errorf("%s action requires assignee", a.Type)
+		}
+	case strings.EqualFold(a.Type, ActionTypeSetState):
+		if a.State == "" {
+			return fmt.Errorf("%s action requires state", a.Type)
+		}
+	case strings.EqualFold(a.Type, ActionTypeBumpPriority):
+		// Linear priorities are 1 (urgent) through 4 (low)
+		if a.Priority < 1 || a.Priority > 4 {
+			return fmt.Errorf("%s action requires a priority between 1 (urgent) and 4 (low)", a.Type)
+		}
+	case strings.EqualFold(a.Type, ActionTypeAddSubscriber):
+		if a.Subscriber == "" {
+			return fmt.Errorf("%s action requires subscriber", a.Type)
+		}
+	case strings.EqualFold(a.Type, ActionTypeSetDueDate):
+		if a.DueInDays < 0 {
+			return fmt.Errorf("%s action requires a non-negative dueInDays", a.Type)
+		}
+	default:
+		return fmt.Errorf("unknown action type %q", a.Type)
+	}
+
+	return nil
+}
+
+// Reference is a workflow state or label named in a team's config, and where it was named
+type Reference struct {
+	Name  string
+	Where string
+}
+
+// StateReferences returns every workflow state named in the team's config
+func (t *TeamConfig) StateReferences() []Reference {
+	refs := make([]Reference, 0)
+	for _, state := range t.IgnoreIssueStates {
+		refs = append(refs, Reference{Name: state, Where: "ignoreIssueStates"})
+	}
+	for _, j := range t.Jobs {
+		where := fmt.Sprintf("job %q", j.Name)
+		for _, f := range j.Filter {
+			refs = append(refs, filterReferences(f, where, true)...)
+		}
+		for _, a := range j.ActionList() {
+			if strings.EqualFold(a.Type, ActionTypeSetState) {
+				refs = append(refs, Reference{Name: a.State, Where: where + ", " + a.Type + " action"})
+			}
+		}
+	}
+	return refs
+}
+
+// LabelReferences returns every label named in the team's config
+func (t *TeamConfig) LabelReferences() []Reference {
+	refs := make([]Reference, 0)
+	for _, j := range t.Jobs {
+		where := fmt.Sprintf("job %q", j.Name)
+		for _, f := range j.Filter {
+			refs = append(refs, filterReferences(f, where, false)...)
+		}
+		for _, a := range j.ActionList() {
+			if strings.EqualFold(a.Type, ActionTypeAddLabel) || strings.EqualFold(a.Type, ActionTypeRemoveLabel) {
+				refs = append(refs, Reference{Name: a.Label, Where: where + ", " + a.Type + " action"})
+			}
+		}
+	}
+	return refs
+}
+
+// filterReferences returns the states or labels named in the filter and its nested filters
+func filterReferences(f Filter, where string, states bool) []Reference {
+	refs := make([]Reference, 0)
+	filterWhere := where + ", " + f.Type + " filter"
+	switch {
+	case states && strings.EqualFold(f.Type, FilterTypeSLA):
+		if f.CurrentState != "" {
+			refs = append(refs, Reference{Name: f.CurrentState, Where: filterWhere + " currentState"})
+		}
+		if f.EnteredState != "" {
+			refs = append(refs, Reference{Name: f.EnteredState, Where: filterWhere + " enteredState"})
+		}
+	case states && strings.EqualFold(f.Type, FilterTypeStateIs) && f.State != "":
+		refs = append(refs, Reference{Name: f.State, Where: filterWhere})
+	case !states && strings.EqualFold(f.Type, FilterTypeHasLabel):
+		refs = append(refs, Reference{Name: f.Label, Where: filterWhere})
+	}
+
+	for _, nested := range f.Filter {
+		refs = append(refs, filterReferences(nested, where, states)...)
+	}
+	return refs
+}
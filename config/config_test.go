@@ -0,0 +1,263 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes the YAML to a temporary file, and returns its path
+func writeConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	f, err := ioutil.TempFile("", "config-*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(yaml); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	return f.Name()
+}
+
+const validJob = `
+job:
+  - name: "sla"
+    filter:
+      - type: SLA
+        currentState: "Todo"
+        longerThan: 8h
+    action:
+      label: "ExceedsSLA"
+`
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"valid", `team: "INT"` + "\ntimeZone: \"America/Denver\"" + validJob, ""},
+		{"team from the command line", `timeZone: "America/Denver"` + validJob, ""},
+		{"unknown field", "team: \"INT\"\ntimeZone: \"America/Denver\"\nlabel: \"x\"", "field label not found"},
+		{"no time zone", `team: "INT"` + validJob, "timeZone is required"},
+		{"no batching", "timeZone: \"UTC\"\nmutationBatchSize: -1", "mutationBatchSize must be at least 1"},
+		{"no workers", "timeZone: \"UTC\"\nworkers: -1", "workers must be at least 1"},
+		{"workspace without a token", "timeZone: \"UTC\"\nworkspaces:\n  - name: other", "tokenEnv is required"},
+		{"workspace named default", "timeZone: \"UTC\"\nworkspaces:\n  - name: default\n    tokenEnv: T", "a name other than"},
+		{"team without a name", "timeZone: \"UTC\"\nteams:\n  - timeZone: \"UTC\"", "team is required"},
+		{
+			"unknown filter",
+			"timeZone: \"UTC\"\njob:\n  - name: j\n    filter:\n      - type: Nope\n    action:\n      label: L",
+			`unknown filter type "Nope"`,
+		},
+		{
+			"unknown state type",
+			"timeZone: \"UTC\"\njob:\n  - name: j\n    filter:\n      - type: SLA\n        currentStateType: doing\n        longerThan: 1h\n    action:\n      label: L",
+			`currentStateType "doing" is not one of`,
+		},
+		{
+			"invalid nested filter",
+			"timeZone: \"UTC\"\njob:\n  - name: j\n    filter:\n      - type: not\n        filter:\n          - type: HasLabel\n    action:\n      label: L",
+			"HasLabel filter requires label",
+		},
+		{
+			"invalid pattern",
+			"timeZone: \"UTC\"\njob:\n  - name: j\n    filter:\n      - type: TitleMatches\n        pattern: \"(\"\n    action:\n      label: L",
+			"invalid pattern",
+		},
+		{
+			"no action",
+			"timeZone: \"UTC\"\njob:\n  - name: j\n    filter:\n      - type: HasLabel\n        label: L",
+			"at least one action is required",
+		},
+		{
+			"comment first",
+			"timeZone: \"UTC\"\njob:\n  - name: j\n    filter:\n      - type: HasLabel\n        label: L\n    actions:\n      - type: Comment\n        comment: hi",
+			"a comment must follow an action",
+		},
+		{
+			"priority out of range",
+			"timeZone: \"UTC\"\njob:\n  - name: j\n    filter:\n      - type: HasLabel\n        label: L\n    actions:\n      - type: BumpPriority\n        priority: 5",
+			"priority between 1 (urgent) and 4 (low)",
+		},
+		{"invalid label color", "team: \"INT\"\ntimeZone: \"UTC\"\nlabels:\n  - name: L\n    color: red", `color "red" is not a hex color`},
+		{"duplicate label", "team: \"INT\"\ntimeZone: \"UTC\"\nlabels:\n  - name: L\n  - name: L", "declared more than once"},
+		{
+			"nested label group",
+			"team: \"INT\"\ntimeZone: \"UTC\"\nlabels:\n  - name: A\n  - name: B\n    parent: A\n  - name: C\n    parent: B",
+			`parent "B" cannot itself have a parent`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.yaml), "")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	c, err := Load(writeConfig(t, `team: "INT"`+"\ntimeZone: \"UTC\""+validJob), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.PageSize != defaultPageSize || c.FullSyncInterval != defaultFullSyncInterval ||
+		c.MutationBatchSize != defaultMutationBatch || c.Workers != defaultWorkers {
+		t.Errorf("Load() = pageSize %d, fullSyncInterval %s, mutationBatchSize %d, workers %d, want the defaults",
+			c.PageSize, c.FullSyncInterval, c.MutationBatchSize, c.Workers)
+	}
+}
+
+func TestAllWorkspaces(t *testing.T) {
+	c := &Config{
+		TeamConfig: TeamConfig{
+			Team:              "INT",
+			TimeZone:          "America/Denver",
+			IgnoreIssueStates: []string{"Done"},
+			Labels:            []Label{{Name: "ExceedsSLA"}},
+		},
+		Teams: []TeamConfig{{Team: "OPS", TimeZone: "UTC"}},
+		Workspaces: []Workspace{{
+			Name:     "other",
+			TokenEnv: "OTHER_TOKEN",
+			Teams:    []TeamConfig{{Team: "ENG", IgnoreIssueStates: []string{}}},
+		}},
+	}
+
+	got := c.AllWorkspaces()
+	want := []Workspace{
+		{Name: DefaultWorkspace, Teams: []TeamConfig{
+			{Team: "INT", TimeZone: "America/Denver", IgnoreIssueStates: []string{"Done"}, Labels: []Label{{Name: "ExceedsSLA"}}},
+			{Team: "OPS", TimeZone: "UTC", IgnoreIssueStates: []string{"Done"}, Labels: []Label{{Name: "ExceedsSLA"}}},
+		}},
+		{Name: "other", TokenEnv: "OTHER_TOKEN", Teams: []TeamConfig{
+			{Team: "ENG", TimeZone: "America/Denver", IgnoreIssueStates: []string{}, Labels: []Label{{Name: "ExceedsSLA"}}},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AllWorkspaces() = %+v, want %+v", got, want)
+	}
+}
+
+func TestActionList(t *testing.T) {
+	tests := []struct {
+		name string
+		job  Job
+		want []Action
+	}{
+		{
+			"label and comment",
+			Job{Action: Action{Label: "L", Comment: "hi"}},
+			[]Action{{Type: ActionTypeAddLabel, Label: "L"}, {Type: ActionTypeComment, Comment: "hi"}},
+		},
+		{
+			"typed action",
+			Job{Action: Action{Type: ActionTypeSetState, State: "Todo"}},
+			[]Action{{Type: ActionTypeSetState, State: "Todo"}},
+		},
+		{
+			"label and further actions",
+			Job{Action: Action{Label: "L"}, Actions: []Action{{Type: ActionTypeBumpPriority, Priority: 1}}},
+			[]Action{{Type: ActionTypeAddLabel, Label: "L"}, {Type: ActionTypeBumpPriority, Priority: 1}},
+		},
+		{"none", Job{}, []Action{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.job.ActionList(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ActionList() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	team := TeamConfig{
+		IgnoreIssueStates: []string{"Done"},
+		Jobs: []Job{{
+			Name: "j",
+			Filter: []Filter{
+				{Type: FilterTypeSLA, CurrentState: "Todo", EnteredState: "Triage", LongerThan: time.Hour},
+				{Type: FilterTypeNot, Filter: []Filter{{Type: FilterTypeHasLabel, Label: "Blocked"}}},
+			},
+			Action:  Action{Label: "ExceedsSLA"},
+			Actions: []Action{{Type: ActionTypeSetState, State: "In Progress"}},
+		}},
+	}
+
+	wantStates := []Reference{
+		{Name: "Done", Where: "ignoreIssueStates"},
+		{Name: "Todo", Where: `job "j", SLA filter currentState`},
+		{Name: "Triage", Where: `job "j", SLA filter enteredState`},
+		{Name: "In Progress", Where: `job "j", SetState action`},
+	}
+	if got := team.StateReferences(); !reflect.DeepEqual(got, wantStates) {
+		t.Errorf("StateReferences() = %+v, want %+v", got, wantStates)
+	}
+
+	wantLabels := []Reference{
+		{Name: "Blocked", Where: `job "j", HasLabel filter`},
+		{Name: "ExceedsSLA", Where: `job "j", AddLabel action`},
+	}
+	if got := team.LabelReferences(); !reflect.DeepEqual(got, wantLabels) {
+		t.Errorf("LabelReferences() = %+v, want %+v", got, wantLabels)
+	}
+}
+
+func TestLoadTeam(t *testing.T) {
+	const teams = "timeZone: \"UTC\"\nteams:\n  - team: \"OPS\"" + "\n    job: []\nworkspaces:\n  - name: other\n    tokenEnv: T\n    teams:\n      - team: \"ENG\"\n"
+
+	tests := []struct {
+		name    string
+		yaml    string
+		team    string
+		want    []string
+		wantErr string
+	}{
+		{"overrides the only team", `team: "INT"` + "\ntimeZone: \"UTC\"" + validJob, "OPS", []string{"default/OPS"}, ""},
+		{"names the top level team", `timeZone: "UTC"` + validJob, "OPS", []string{"default/OPS"}, ""},
+		{"selects a listed team", teams, "OPS", []string{"default/OPS"}, ""},
+		{"selects a team in another workspace", teams, "eng", []string{"other/ENG"}, ""},
+		{"selects the top level team", `team: "INT"` + "\n" + teams, "INT", []string{"default/INT"}, ""},
+		{"names the top level team alongside listed teams", teams + validJob, "SUP", []string{"default/SUP"}, ""},
+		{"team not in the config", teams, "SUP", nil, `team "SUP" is not in the config`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Load(writeConfig(t, tt.yaml), tt.team)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			got := make([]string, 0)
+			for _, w := range c.AllWorkspaces() {
+				for _, team := range w.Teams {
+					got = append(got, w.Name+"/"+team.Team)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() teams = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,8 +3,9 @@ module github.com/jmartin127/linear-autolabeler
 go 1.14
 
 require (
-	github.com/graphql-go/graphql v0.7.9
+	github.com/graphql-go/graphql v0.8.1
 	github.com/machinebox/graphql v0.2.2
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/rickar/cal/v2 v2.0.0-beta.2
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,280 @@
+package linear
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/machinebox/graphql"
+)
+
+// Operation is a single mutation of an issue, which can be sent together with others in one request
+type Operation struct {
+	// Mutation is the name of the mutation, e.g. issueUpdate
+	Mutation string
+	// Issue is the ticket number of the issue, e.g. INT-123
+	Issue string
+	// Err is set once the operation has been sent, if it failed
+	Err error
+
+	args []operationArg
+}
+
+// operationArg is an argument of the mutation, which is sent as a variable of the given GraphQL type
+type operationArg struct {
+	name    string
+	gqlType string
+	value   interface{}
+}
+
+func issueUpdateOperation(ticketNumber string, input IssueUpdateInput) *Operation {
+	return &Operation{Mutation: "issueUpdate", Issue: ticketNumber, args: []operationArg{
+		{name: "id", gqlType: "String!", value: ticketNumber},
+		{name: "input", gqlType: "IssueUpdateInput!", value: input},
+	}}
+}
+
+func commentCreateOperation(ticketNumber string, input CommentCreateInput) *Operation {
+	return &Operation{Mutation: "commentCreate", Issue: ticketNumber, args: []operationArg{
+		{name: "input", gqlType: "CommentCreateInput!", value: input},
+	}}
+}
+
+func issueLabelOperation(mutation string, ticketNumber string, labelID string) *Operation {
+	return &Operation{Mutation: mutation, Issue: ticketNumber, args: []operationArg{
+		{name: "id", gqlType: "String!", value: ticketNumber},
+		{name: "labelId", gqlType: "String!", value: labelID},
+	}}
+}
+
+func issueSubscribeOperation(ticketNumber string, userID string) *Operation {
+	return &Operation{Mutation: "issueSubscribe", Issue: ticketNumber, args: []operationArg{
+		{name: "id", gqlType: "String!", value: ticketNumber},
+		{name: "userId", gqlType: "String", value: userID},
+	}}
+}
+
+// Batcher collects operations, and sends them as aliased mutations in requests of up to size operations. A client
+// created with Batched adds its issue mutations to the batcher rather than sending them. Each issue's operations are
+// held until the issue is released, and are then sent together in one request, in the order they were added, so that
+// e.g. a comment is never sent before the label it follows.
+type Batcher struct {
+	lc   *LinearClient
+	size int
+
+	mu   sync.Mutex
+	held map[string][]*Operation
+	// pending are the operations of the released issues, one slice per issue
+	pending [][]*Operation
+	queued  int
+}
+
+// NewBatcher creates a batcher which sends its operations with the client, size at a time
+func NewBatcher(lc *LinearClient, size int) *Batcher {
+	if size < 1 {
+		size = 1
+	}
+	return &Batcher{lc: lc, size: size, held: make(map[string][]*Operation)}
+}
+
+// Add holds the operation with the rest of its issue's operations, until the issue is released
+func (b *Batcher) Add(op *Operation) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.held[op.Issue] = append(b.held[op.Issue], op)
+}
+
+// Release queues the operations held for the issue, given by its ticket number, to be sent by the next Flush. It is
+// called once every change has been made to the issue.
+func (b *Batcher) Release(ticketNumber string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ops := b.held[ticketNumber]
+	delete(b.held, ticketNumber)
+	if len(ops) > 0 {
+		b.pending = append(b.pending, ops)
+		b.queued += len(ops)
+	}
+}
+
+// Full returns true once a whole request's worth of operations is queued
+func (b *Batcher) Full() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.queued >= b.size
+}
+
+// Flush sends every queued operation and returns them in the order they were queued, each with its Err set if it
+// failed. An issue's operations are never split between requests, so a request holds more than size operations when a
+// single issue has that many. Once a request fails in a way which would fail every operation, e.g. an invalid token,
+// the rest are not sent and fail with the same error.
+func (b *Batcher) Flush(ctx context.Context) []*Operation {
+	b.mu.Lock()
+	issues := b.pending
+	b.pending = nil
+	b.queued = 0
+	b.mu.Unlock()
+
+	// pack whole issues into requests
+	requests := make([][]*Operation, 0)
+	var request []*Operation
+	for _, ops := range issues {
+		if len(request) > 0 && len(request)+len(ops) > b.size {
+			requests = append(requests, request)
+			request = nil
+		}
+		request = append(request, ops...)
+	}
+	if len(request) > 0 {
+		requests = append(requests, request)
+	}
+
+	all := make([]*Operation, 0)
+	var stopErr error
+	for _, ops := range requests {
+		all = append(all, ops...)
+		if stopErr != nil {
+			for _, op := range ops {
+				op.Err = stopErr
+			}
+			continue
+		}
+		log.Printf("Sending %d mutations\n", len(ops))
+		if err := b.lc.sendOperations(ctx, ops); err != nil && !Skippable(err) {
+			stopErr = err
+		}
+	}
+
+	return all
+}
+
+// Batched returns a copy of the client which adds its issue mutations to the batcher rather than sending them. Those
+// methods then report success straight away, and record the change on the issue, so the actual result of each
+// mutation is only known once the issue has been released and the batcher flushed.
+func (lc *LinearClient) Batched(b *Batcher) *LinearClient {
+	batched := *lc
+	batched.batcher = b
+	return &batched
+}
+
+// mutate sends the operation, or queues it if the client is batched. A dry run client drops it.
+func (lc *LinearClient) mutate(ctx context.Context, op *Operation) error {
+	if lc.dryRun != nil {
+		return nil
+	}
+	if lc.batcher != nil {
+		lc.batcher.Add(op)
+		return nil
+	}
+
+	lc.sendOperations(ctx, []*Operation{op})
+	return op.Err
+}
+
+// sendOperations sends the operations in one request, aliased op0, op1, and so on, and sets the Err of each which
+// failed. GraphQL errors are matched to their operation by the alias at the start of their path. Any other error, such
+// as a failure to reach Linear, fails every operation without a result of its own, and is returned.
+//
+// Linear's mutations return non-null payloads, so when one fails the error nulls the whole response, and the mutations
+// after it are not run. Those before it were run, so only the later ones are sent again, apart from those of the same
+// issue, which fail.
+func (lc *LinearClient) sendOperations(ctx context.Context, ops []*Operation) error {
+	var response map[string]*SuccessResponse
+	err := lc.executeMutation(ctx, batchRequest(ops), &response)
+
+	// only GraphQL errors which say which field failed can be blamed on a single operation
+	requestErr := err
+	opErrors := make(map[string][]*GraphQLError)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Kind == KindValidation {
+		requestErr = nil
+		for _, e := range apiErr.GraphQLErrors {
+			alias := ""
+			if len(e.Path) > 0 {
+				alias, _ = e.Path[0].(string)
+			}
+			if alias == "" {
+				requestErr = err
+				continue
+			}
+			opErrors[alias] = append(opErrors[alias], e)
+		}
+	}
+
+	// when a single mutation's error nulled the response, the results of the others are unknown rather than failed
+	failed := len(ops)
+	nulled := err != nil && requestErr == nil && len(response) == 0 && len(opErrors) == 1
+	if nulled {
+		for i := range ops {
+			if len(opErrors[operationAlias(i)]) > 0 {
+				failed = i
+				break
+			}
+		}
+	}
+
+	for i, op := range ops {
+		alias := operationAlias(i)
+		result := response[alias]
+		switch {
+		case len(opErrors[alias]) > 0:
+			errs := opErrors[alias]
+			op.Err = &APIError{Kind: apiErr.Kind, StatusCode: apiErr.StatusCode, GraphQLErrors: errs, Err: errs[0]}
+		case nulled:
+			// run before the mutation which failed, or sent again below
+		case result == nil && requestErr != nil:
+			op.Err = requestErr
+		case result == nil || !result.Success:
+			op.Err = &MutationFailedError{Mutation: op.Mutation, ID: op.Issue}
+		}
+	}
+
+	// the failed issue's later operations are not sent, as they may depend on the one which failed, e.g. a comment on a
+	// label which was not added
+	if nulled && failed+1 < len(ops) {
+		rest := make([]*Operation, 0, len(ops)-failed-1)
+		for _, op := range ops[failed+1:] {
+			if op.Issue == ops[failed].Issue {
+				op.Err = fmt.Errorf("not sent, as %s failed: %w", ops[failed].Mutation, ops[failed].Err)
+				continue
+			}
+			rest = append(rest, op)
+		}
+		if len(rest) > 0 {
+			return lc.sendOperations(ctx, rest)
+		}
+	}
+
+	return requestErr
+}
+
+// batchRequest builds a single mutation of every operation, numbering their variables to keep them apart
+func batchRequest(ops []*Operation) *graphql.Request {
+	params := make([]string, 0)
+	fields := make([]string, 0, len(ops))
+	vars := make(map[string]interface{})
+	for i, op := range ops {
+		args := make([]string, 0, len(op.args))
+		for _, a := range op.args {
+			name := fmt.Sprintf("%s%d", a.name, i)
+			params = append(params, fmt.Sprintf("$%s: %s", name, a.gqlType))
+			args = append(args, fmt.Sprintf("%s: $%s", a.name, name))
+			vars[name] = a.value
+		}
+		fields = append(fields, fmt.Sprintf("  %s: %s(%s) {\n    success\n  }", operationAlias(i), op.Mutation, strings.Join(args, ", ")))
+	}
+
+	req := graphql.NewRequest(fmt.Sprintf("mutation(%s) {\n%s\n}", strings.Join(params, ", "), strings.Join(fields, "\n")))
+	for name, value := range vars {
+		req.Var(name, value)
+	}
+
+	return req
+}
+
+func operationAlias(i int) string {
+	return fmt.Sprintf("op%d", i)
+}
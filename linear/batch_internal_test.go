@@ -0,0 +1,162 @@
+package linear
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// scriptedServer answers each request with the next of its responses, and records the requests
+type scriptedServer struct {
+	*httptest.Server
+	responses []string
+	requests  []scriptedRequest
+}
+
+type scriptedRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+func newScriptedServer(t *testing.T, responses ...string) *scriptedServer {
+	t.Helper()
+	s := &scriptedServer{responses: responses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req scriptedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.requests = append(s.requests, req)
+		if len(s.responses) == 0 {
+			http.Error(w, "no response left", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(s.responses[0]))
+		s.responses = s.responses[1:]
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *scriptedServer) client() *LinearClient {
+	return NewLinearClient("test-token", WithBaseURL(s.URL), WithRetryPolicy(RetryPolicy{}))
+}
+
+func TestBatchRequest(t *testing.T) {
+	s := newScriptedServer(t, `{"data":{"op0":{"success":true},"op1":{"success":true}}}`)
+	ops := []*Operation{
+		issueLabelOperation("issueAddLabel", "INT-1", "label-1"),
+		commentCreateOperation("INT-1", CommentCreateInput{IssueID: "INT-1", Body: "Exceeds the SLA"}),
+	}
+	if err := s.client().sendOperations(context.Background(), ops); err != nil {
+		t.Fatal(err)
+	}
+
+	req := s.requests[0]
+	wantQuery := "mutation($id0: String!, $labelId0: String!, $input1: CommentCreateInput!) {\n" +
+		"  op0: issueAddLabel(id: $id0, labelId: $labelId0) {\n    success\n  }\n" +
+		"  op1: commentCreate(input: $input1) {\n    success\n  }\n}"
+	if req.Query != wantQuery {
+		t.Errorf("query = %q, want %q", req.Query, wantQuery)
+	}
+	wantVars := map[string]interface{}{
+		"id0":      "INT-1",
+		"labelId0": "label-1",
+		"input1":   map[string]interface{}{"issueId": "INT-1", "body": "Exceeds the SLA"},
+	}
+	if !reflect.DeepEqual(req.Variables, wantVars) {
+		t.Errorf("variables = %v, want %v", req.Variables, wantVars)
+	}
+}
+
+func TestSendOperations(t *testing.T) {
+	notSent := func(err error) bool { return err != nil && strings.HasPrefix(err.Error(), "not sent") }
+	mutationFailed := func(err error) bool {
+		var mutationErr *MutationFailedError
+		return errors.As(err, &mutationErr)
+	}
+	notFound := func(err error) bool { return errors.Is(err, ErrNotFound) }
+	succeeded := func(err error) bool { return err == nil }
+	validation := func(err error) bool { return errors.Is(err, ErrValidation) && !Skippable(err) }
+
+	tests := []struct {
+		name      string
+		responses []string
+		// want checks each operation's Err, for the operations issueAddLabel INT-1, issueAddLabel INT-2,
+		// commentCreate INT-2 and issueAddLabel INT-3
+		want []func(error) bool
+		// wantResent are the issues of the operations sent again after the first request
+		wantResent []string
+		wantErr    bool
+	}{
+		{
+			"mutation failing mid-batch",
+			[]string{
+				`{"data":null,"errors":[{"message":"Entity not found","path":["op1"]}]}`,
+				`{"data":{"op0":{"success":true}}}`,
+			},
+			[]func(error) bool{succeeded, notFound, notSent, succeeded},
+			[]string{"INT-3"},
+			false,
+		},
+		{
+			"GraphQL error without a path",
+			[]string{`{"data":null,"errors":[{"message":"Query too complex"}]}`},
+			[]func(error) bool{validation, validation, validation, validation},
+			nil,
+			true,
+		},
+		{
+			"unsuccessful mutations and errors",
+			[]string{`{"data":{"op0":{"success":true},"op1":{"success":false},"op2":{"success":true},"op3":null},` +
+				`"errors":[{"message":"Entity not found","path":["op3"]}]}`},
+			[]func(error) bool{succeeded, mutationFailed, succeeded, notFound},
+			nil,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScriptedServer(t, tt.responses...)
+			ops := []*Operation{
+				issueLabelOperation("issueAddLabel", "INT-1", "label-1"),
+				issueLabelOperation("issueAddLabel", "INT-2", "label-1"),
+				commentCreateOperation("INT-2", CommentCreateInput{IssueID: "INT-2", Body: "Exceeds the SLA"}),
+				issueLabelOperation("issueAddLabel", "INT-3", "label-1"),
+			}
+
+			err := s.client().sendOperations(context.Background(), ops)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("sendOperations() error = %v, want an error %t", err, tt.wantErr)
+			}
+			for i, op := range ops {
+				if !tt.want[i](op.Err) {
+					t.Errorf("%s %s has unexpected error %v", op.Mutation, op.Issue, op.Err)
+				}
+			}
+
+			// the operations sent again are all labels, with the issue in their id variable
+			resent := make([]string, 0)
+			for _, req := range s.requests[1:] {
+				for i := 0; ; i++ {
+					id, ok := req.Variables[fmt.Sprintf("id%d", i)].(string)
+					if !ok {
+						break
+					}
+					resent = append(resent, id)
+				}
+			}
+			if len(resent) != len(tt.wantResent) || (len(resent) > 0 && !reflect.DeepEqual(resent, tt.wantResent)) {
+				t.Errorf("sent again %v, want %v", resent, tt.wantResent)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package linear_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jmartin127/linear-autolabeler/linear"
+	"github.com/jmartin127/linear-autolabeler/linear/lineartest"
+)
+
+// batchTest is a fake server with a team, a label, and issues INT-1 to INT-n, along with a batched client
+type batchTest struct {
+	srv     *lineartest.Server
+	lc      *linear.LinearClient
+	batcher *linear.Batcher
+	batched *linear.LinearClient
+	label   linear.IssueLabelNode
+	added   []*lineartest.Issue
+	issues  []*linear.IssueNode
+}
+
+func newBatchTest(t *testing.T, issues, size int) *batchTest {
+	t.Helper()
+	srv := lineartest.NewServer()
+	t.Cleanup(srv.Close)
+	team := srv.AddTeam("INT", "Integrations")
+	state := srv.AddState(team, "Todo", linear.StateTypeUnstarted)
+	label := srv.AddLabel(team, "ExceedsSLA")
+
+	lc := srv.Client(linear.WithRetryPolicy(linear.RetryPolicy{}))
+	bt := &batchTest{srv: srv, lc: lc, batcher: linear.NewBatcher(lc, size), label: linear.IssueLabelNode{ID: label.ID, Name: label.Name}}
+	bt.batched = lc.Batched(bt.batcher)
+	for i := 1; i <= issues; i++ {
+		bt.added = append(bt.added, srv.AddIssue(team, fmt.Sprintf("Issue %d", i), state))
+		issue, err := lc.GetIssue(context.Background(), fmt.Sprintf("INT-%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bt.issues = append(bt.issues, issue)
+	}
+	return bt
+}
+
+// labelAndComment queues a label and a comment for the issue, and releases it
+func (bt *batchTest) labelAndComment(t *testing.T, issue *linear.IssueNode) {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := bt.batched.AddLabelToTicket(ctx, issue, bt.label); err != nil {
+		t.Fatal(err)
+	}
+	if err := bt.batched.AddCommentToTicket(ctx, issue, "Exceeds the SLA"); err != nil {
+		t.Fatal(err)
+	}
+	bt.batcher.Release(linear.TicketNumber(issue))
+}
+
+// flush flushes the batcher, and returns the operations and how many requests were sent
+func (bt *batchTest) flush() ([]*linear.Operation, int) {
+	before := bt.srv.Requests()
+	ops := bt.batcher.Flush(context.Background())
+	return ops, bt.srv.Requests() - before
+}
+
+// sent describes the mutations the server received, e.g. issueAddLabel INT-1
+func (bt *batchTest) sent() []string {
+	sent := make([]string, 0)
+	for _, m := range bt.srv.Mutations() {
+		id, ok := m.Args["id"].(string)
+		if input, isInput := m.Args["input"].(map[string]interface{}); !ok && isInput {
+			id, _ = input["issueId"].(string)
+		}
+		if issue, ok := bt.srv.Issue(id); ok {
+			id = fmt.Sprintf("INT-%d", issue.Number)
+		}
+		sent = append(sent, m.Name+" "+id)
+	}
+	return sent
+}
+
+func TestBatcherHoldsIssuesUntilReleased(t *testing.T) {
+	bt := newBatchTest(t, 1, 2)
+	ctx := context.Background()
+	issue := bt.issues[0]
+	if _, err := bt.batched.AddLabelToTicket(ctx, issue, bt.label); err != nil {
+		t.Fatal(err)
+	}
+	if err := bt.batched.AddCommentToTicket(ctx, issue, "Exceeds the SLA"); err != nil {
+		t.Fatal(err)
+	}
+
+	if bt.batcher.Full() {
+		t.Error("batcher is full before the issue was released")
+	}
+	if ops, requests := bt.flush(); len(ops) != 0 || requests != 0 {
+		t.Errorf("flushed %d operations in %d requests before the issue was released", len(ops), requests)
+	}
+
+	bt.batcher.Release(linear.TicketNumber(issue))
+	if !bt.batcher.Full() {
+		t.Error("batcher is not full once the issue was released")
+	}
+	ops, requests := bt.flush()
+	if len(ops) != 2 || requests != 1 {
+		t.Errorf("flushed %d operations in %d requests, want 2 in 1", len(ops), requests)
+	}
+	if want := []string{"issueAddLabel INT-1", "commentCreate INT-1"}; !reflect.DeepEqual(bt.sent(), want) {
+		t.Errorf("sent %v, want %v", bt.sent(), want)
+	}
+}
+
+func TestBatcherKeepsIssuesInOneRequest(t *testing.T) {
+	tests := []struct {
+		name         string
+		issues, size int
+		wantRequests int
+	}{
+		{"several issues per request", 4, 4, 2},
+		{"issue split at the size", 3, 3, 3},
+		{"issue larger than the size", 2, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bt := newBatchTest(t, tt.issues, tt.size)
+			want := make([]string, 0)
+			for _, issue := range bt.issues {
+				bt.labelAndComment(t, issue)
+				ticketNumber := linear.TicketNumber(issue)
+				want = append(want, "issueAddLabel "+ticketNumber, "commentCreate "+ticketNumber)
+			}
+
+			ops, requests := bt.flush()
+			if len(ops) != 2*tt.issues || requests != tt.wantRequests {
+				t.Errorf("flushed %d operations in %d requests, want %d in %d", len(ops), requests, 2*tt.issues, tt.wantRequests)
+			}
+			for _, op := range ops {
+				if op.Err != nil {
+					t.Errorf("%s %s failed: %v", op.Mutation, op.Issue, op.Err)
+				}
+			}
+			if !reflect.DeepEqual(bt.sent(), want) {
+				t.Errorf("sent %v, want %v", bt.sent(), want)
+			}
+		})
+	}
+}
+
+func TestBatcherDoesNotSendAfterAnIssueFails(t *testing.T) {
+	bt := newBatchTest(t, 3, 10)
+	bt.srv.DeleteIssue(bt.added[1])
+	for _, issue := range bt.issues {
+		bt.labelAndComment(t, issue)
+	}
+	bt.srv.ResetMutations()
+
+	ops, _ := bt.flush()
+	for _, op := range ops {
+		if failed := op.Err != nil; failed != (op.Issue == "INT-2") {
+			t.Errorf("%s %s has error %v", op.Mutation, op.Issue, op.Err)
+		}
+	}
+	// the deleted issue's comment is not sent after its label failed, but the next issue's mutations are
+	want := []string{"issueAddLabel INT-1", "commentCreate INT-1", "issueAddLabel INT-2", "issueAddLabel INT-3", "commentCreate INT-3"}
+	if !reflect.DeepEqual(bt.sent(), want) {
+		t.Errorf("sent %v, want %v", bt.sent(), want)
+	}
+}
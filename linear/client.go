@@ -0,0 +1,168 @@
+package linear
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/machinebox/graphql"
+)
+
+const (
+	defaultBaseURL   = "https://api.linear.app/graphql"
+	defaultUserAgent = "linear-autolabeler"
+)
+
+type LinearClient struct {
+	token     string
+	baseURL   string
+	userAgent string
+	timeout   time.Duration
+
+	httpClient    *http.Client
+	graphqlClient *graphql.Client
+	retryPolicy   RetryPolicy
+	rateLimiter   *rateLimiter
+
+	// batcher queues issue mutations, if the client was created with Batched
+	batcher *Batcher
+	// dryRun is set if the client was created with DryRun, in which case no mutations are sent
+	dryRun *dryRun
+}
+
+// Option configures a LinearClient
+type Option func(*LinearClient)
+
+// WithBaseURL sets the URL of the GraphQL endpoint, e.g. to point the client at a test server
+func WithBaseURL(baseURL string) Option {
+	return func(lc *LinearClient) {
+		lc.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for every request, so that connections are reused
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(lc *LinearClient) {
+		lc.httpClient = httpClient
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(lc *LinearClient) {
+		lc.userAgent = userAgent
+	}
+}
+
+// WithTimeout limits how long each request may take. Zero means no limit.
+func WithTimeout(timeout time.Duration) Option {
+	return func(lc *LinearClient) {
+		lc.timeout = timeout
+	}
+}
+
+// WithRetryPolicy sets how failed requests are retried, instead of DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(lc *LinearClient) {
+		lc.retryPolicy = policy
+	}
+}
+
+// NewLinearClient creates a client which authenticates with the given developer token
+func NewLinearClient(token string, opts ...Option) *LinearClient {
+	lc := &LinearClient{
+		token:       token,
+		baseURL:     defaultBaseURL,
+		userAgent:   defaultUserAgent,
+		httpClient:  http.DefaultClient,
+		retryPolicy: DefaultRetryPolicy,
+		rateLimiter: &rateLimiter{},
+	}
+	for _, opt := range opts {
+		opt(lc)
+	}
+
+	lc.graphqlClient = graphql.NewClient(lc.baseURL, graphql.WithHTTPClient(withCapture(lc.httpClient)))
+
+	return lc
+}
+
+// exectueQuery runs the request, retrying it according to the retry policy. Failures are returned as an *APIError.
+func (lc *LinearClient) exectueQuery(ctx context.Context, graphqlRequest *graphql.Request, response interface{}) error {
+	return lc.execute(ctx, graphqlRequest, response, isRetryable)
+}
+
+// executeMutation runs the mutation like exectueQuery, but only retries it when Linear is known not to have run it.
+// A mutation which timed out may still have been made, and sending it again could e.g. post a comment twice.
+func (lc *LinearClient) executeMutation(ctx context.Context, graphqlRequest *graphql.Request, response interface{}) error {
+	return lc.execute(ctx, graphqlRequest, response, isResendable)
+}
+
+// execute runs the request, retrying the failures which retryable accepts
+func (lc *LinearClient) execute(ctx context.Context, graphqlRequest *graphql.Request, response interface{}, retryable func(error) bool) error {
+	graphqlRequest.Header.Set("Authorization", lc.token)
+	graphqlRequest.Header.Set("User-Agent", lc.userAgent)
+
+	attempts := lc.retryPolicy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := lc.retryPolicy.backoff(attempt - 1)
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+				delay = apiErr.RetryAfter
+			}
+			log.Printf("Retrying request in %s after error: %v\n", delay, err)
+			if err := sleep(ctx, delay); err != nil {
+				return err
+			}
+		}
+
+		if err := lc.rateLimiter.wait(ctx); err != nil {
+			return err
+		}
+
+		err = lc.run(ctx, graphqlRequest, response)
+		if err == nil || !retryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// run makes a single attempt at the request
+func (lc *LinearClient) run(ctx context.Context, graphqlRequest *graphql.Request, response interface{}) error {
+	res := &httpResponse{}
+	runCtx := context.WithValue(ctx, httpResponseKey{}, res)
+	if lc.timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, lc.timeout)
+		defer cancel()
+	}
+
+	err := lc.graphqlClient.Run(runCtx, graphqlRequest, response)
+	if res.received {
+		lc.rateLimiter.update(res.header)
+		if err == nil && res.statusCode >= 300 {
+			err = fmt.Errorf("unexpected status %d", res.statusCode)
+		}
+	}
+	if err == nil {
+		return nil
+	}
+
+	// do not retry once the caller has given up
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return classify(err, res)
+}
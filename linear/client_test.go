@@ -0,0 +1,123 @@
+package linear_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jmartin127/linear-autolabeler/linear"
+	"github.com/jmartin127/linear-autolabeler/linear/lineartest"
+)
+
+// retryPolicy retries quickly, so that the tests do not wait on the backoff
+var retryPolicy = linear.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+// newRetryServer returns a fake server with issue INT-1
+func newRetryServer(t *testing.T) *lineartest.Server {
+	t.Helper()
+	srv := lineartest.NewServer()
+	t.Cleanup(srv.Close)
+	team := srv.AddTeam("INT", "Integrations")
+	srv.AddIssue(team, "Customer cannot log in", srv.AddState(team, "Todo", linear.StateTypeUnstarted))
+	return srv
+}
+
+// failingDial is a transport whose first request fails to connect, before reaching the server
+type failingDial struct {
+	next   http.RoundTripper
+	failed bool
+}
+
+func (t *failingDial) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.failed {
+		t.failed = true
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	}
+	return t.next.RoundTrip(req)
+}
+
+func TestRetries(t *testing.T) {
+	query := func(ctx context.Context, lc *linear.LinearClient) error {
+		_, err := lc.GetIssue(ctx, "INT-1")
+		return err
+	}
+	mutation := func(ctx context.Context, lc *linear.LinearClient) error {
+		return lc.SetPriority(ctx, "INT-1", 2)
+	}
+
+	tests := []struct {
+		name         string
+		status       int
+		dialError    bool
+		request      func(ctx context.Context, lc *linear.LinearClient) error
+		wantErr      error
+		wantRequests int
+	}{
+		{"query after a server error", http.StatusBadGateway, false, query, nil, 2},
+		{"mutation after a server error", http.StatusBadGateway, false, mutation, linear.ErrServer, 1},
+		{"query after a dial error", 0, true, query, nil, 1},
+		{"mutation after a dial error", 0, true, mutation, nil, 1},
+		{"query when unauthorized", http.StatusUnauthorized, false, query, linear.ErrUnauthorized, 1},
+		{"mutation when unauthorized", http.StatusUnauthorized, false, mutation, linear.ErrUnauthorized, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newRetryServer(t)
+			opts := []linear.Option{linear.WithRetryPolicy(retryPolicy)}
+			if tt.dialError {
+				opts = append(opts, linear.WithHTTPClient(&http.Client{Transport: &failingDial{next: srv.Server.Client().Transport}}))
+			}
+			lc := srv.Client(opts...)
+			if tt.status != 0 {
+				srv.FailNext(tt.status, nil)
+			}
+
+			err := tt.request(context.Background(), lc)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("request error = %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("request error = %v, want %v", err, tt.wantErr)
+			}
+			if got := srv.Requests(); got != tt.wantRequests {
+				t.Errorf("server received %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestRetryWaitsForRateLimitReset(t *testing.T) {
+	for _, mutation := range []bool{false, true} {
+		srv := newRetryServer(t)
+		lc := srv.Client(linear.WithRetryPolicy(retryPolicy))
+
+		const wait = 300 * time.Millisecond
+		reset := time.Now().Add(wait).UnixNano() / int64(time.Millisecond)
+		srv.FailNext(http.StatusTooManyRequests, http.Header{
+			"X-Ratelimit-Requests-Remaining": {"0"},
+			"X-Ratelimit-Requests-Reset":     {strconv.FormatInt(reset, 10)},
+		})
+
+		start := time.Now()
+		var err error
+		if mutation {
+			err = lc.SetPriority(context.Background(), "INT-1", 2)
+		} else {
+			_, err = lc.GetIssue(context.Background(), "INT-1")
+		}
+		if err != nil {
+			t.Fatalf("mutation %t: request error = %v", mutation, err)
+		}
+		// the reset is only given to the millisecond
+		if elapsed := time.Since(start); elapsed < wait-time.Millisecond {
+			t.Errorf("mutation %t: retried after %s, want at least %s", mutation, elapsed, wait)
+		}
+		if got := srv.Requests(); got != 2 {
+			t.Errorf("mutation %t: server received %d requests, want 2", mutation, got)
+		}
+	}
+}
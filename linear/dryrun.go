@@ -0,0 +1,38 @@
+package linear
+
+import "sync"
+
+// dryRun holds the labels a dry run client has pretended to create
+type dryRun struct {
+	mu     sync.Mutex
+	labels []IssueLabelNode
+}
+
+// DryRun returns a copy of the client which sends no mutations, so that a run can be planned without changing anything
+// in Linear. Its mutations report success and record the change on the issue as usual. Labels it creates are given a
+// placeholder ID, and are listed by GetTeamLabels along with the team's actual labels.
+func (lc *LinearClient) DryRun() *LinearClient {
+	dry := *lc
+	dry.dryRun = &dryRun{}
+	dry.batcher = nil
+	return &dry
+}
+
+func (d *dryRun) createLabel(input LabelCreateInput) *IssueLabelNode {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	label := IssueLabelNode{ID: "dry-run-" + input.Name, Name: input.Name, Color: input.Color, Description: input.Description}
+	if input.ParentID != "" {
+		label.Parent = &IssueLabelNode{ID: input.ParentID}
+	}
+	d.labels = append(d.labels, label)
+
+	return &label
+}
+
+func (d *dryRun) createdLabels() []IssueLabelNode {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]IssueLabelNode(nil), d.labels...)
+}
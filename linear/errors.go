@@ -0,0 +1,142 @@
+package linear
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrorKind classifies the errors returned when calling the Linear API
+type ErrorKind int
+
+const (
+	// KindNetwork is a failure to reach Linear, or to read its response
+	KindNetwork ErrorKind = iota + 1
+	// KindRateLimited is a request rejected because the rate limit was exceeded
+	KindRateLimited
+	// KindServer is a 5xx response from Linear
+	KindServer
+	// KindValidation is a GraphQL error, e.g. a query which does not match the schema
+	KindValidation
+	// KindAuth is a request rejected because the token is invalid or lacks access
+	KindAuth
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindNetwork:
+		return "network error"
+	case KindRateLimited:
+		return "rate limited"
+	case KindServer:
+		return "server error"
+	case KindValidation:
+		return "validation error"
+	case KindAuth:
+		return "unauthorized"
+	}
+	return "unknown error"
+}
+
+// ErrNotFound is returned when a team, issue, label, user, or workflow state does not exist
+var ErrNotFound = errors.New("linear: not found")
+
+// Sentinel errors for each kind of APIError, for use with errors.Is
+var (
+	ErrNetwork      = errors.New("linear: network error")
+	ErrRateLimited  = errors.New("linear: rate limited")
+	ErrServer       = errors.New("linear: server error")
+	ErrValidation   = errors.New("linear: validation error")
+	ErrUnauthorized = errors.New("linear: unauthorized")
+)
+
+// APIError is returned when a request to the Linear API fails, once any retries have been exhausted. When the
+// response contained GraphQL errors, Err is the first of them.
+type APIError struct {
+	Kind ErrorKind
+	// StatusCode is the HTTP status of the response, or zero if no response was received
+	StatusCode int
+	// RetryAfter is how long Linear asked us to wait before retrying, if it said
+	RetryAfter time.Duration
+	// GraphQLErrors are all of the errors in the response
+	GraphQLErrors []*GraphQLError
+	Err           error
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("linear: %s (status %d): %v", e.Kind, e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("linear: %s: %v", e.Kind, e.Err)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the sentinel error for the kind of error
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNetwork:
+		return e.Kind == KindNetwork
+	case ErrRateLimited:
+		return e.Kind == KindRateLimited
+	case ErrServer:
+		return e.Kind == KindServer
+	case ErrValidation:
+		return e.Kind == KindValidation
+	case ErrUnauthorized:
+		return e.Kind == KindAuth
+	}
+	return false
+}
+
+// Retryable returns true if the request may succeed when tried again
+func (e *APIError) Retryable() bool {
+	return e.Kind == KindNetwork || e.Kind == KindRateLimited || e.Kind == KindServer
+}
+
+// GraphQLError is a single error from the errors of a GraphQL response
+type GraphQLError struct {
+	Message string `json:"message"`
+	// Path is the path to the field which failed, e.g. ["issue", "labels"]
+	Path       []interface{}          `json:"path"`
+	Extensions map[string]interface{} `json:"extensions"`
+}
+
+func (e *GraphQLError) Error() string {
+	if len(e.Path) > 0 {
+		return fmt.Sprintf("graphql: %s (path %v)", e.Message, e.Path)
+	}
+	return fmt.Sprintf("graphql: %s", e.Message)
+}
+
+// Code returns the extensions.code of the error, if any
+func (e *GraphQLError) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// Is matches ErrNotFound when Linear could not find the entity requested
+func (e *GraphQLError) Is(target error) bool {
+	return target == ErrNotFound && (e.Message == "Entity not found" || e.Code() == "NOT_FOUND")
+}
+
+// MutationFailedError is returned when Linear accepts a mutation, but reports that it did not succeed
+type MutationFailedError struct {
+	// Mutation is the name of the mutation, e.g. issueUpdate
+	Mutation string
+	// ID is the issue the mutation was for
+	ID string
+}
+
+func (e *MutationFailedError) Error() string {
+	return fmt.Sprintf("linear: %s did not succeed for %s", e.Mutation, e.ID)
+}
+
+// Skippable returns true if the error only affects the issue being processed, so processing can move on to the next
+// issue. Errors such as an invalid token or a query which does not match the schema would fail for every issue.
+func Skippable(err error) bool {
+	var mutationErr *MutationFailedError
+	return errors.Is(err, ErrNotFound) || errors.As(err, &mutationErr)
+}
@@ -0,0 +1,60 @@
+package linear
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrors(t *testing.T) {
+	notFound := &GraphQLError{Message: "Entity not found", Path: []interface{}{"issue"}}
+	notFoundCode := &GraphQLError{Message: "Issue does not exist", Extensions: map[string]interface{}{"code": "NOT_FOUND"}}
+	invalid := &GraphQLError{Message: "Cannot query field \"nope\" on type \"Issue\""}
+	mutationFailed := &MutationFailedError{Mutation: "issueUpdate", ID: "INT-1"}
+
+	tests := []struct {
+		name               string
+		err                error
+		wantNotFound       bool
+		wantMutationFailed bool
+		wantSkippable      bool
+	}{
+		{"entity not found", &APIError{Kind: KindValidation, StatusCode: http.StatusOK, GraphQLErrors: []*GraphQLError{notFound}, Err: notFound}, true, false, true},
+		{"not found code", &APIError{Kind: KindValidation, StatusCode: http.StatusOK, GraphQLErrors: []*GraphQLError{notFoundCode}, Err: notFoundCode}, true, false, true},
+		{"wrapped not found", fmt.Errorf("getting issue INT-1: %w", &APIError{Kind: KindValidation, Err: notFound}), true, false, true},
+		{"mutation failed", mutationFailed, false, true, true},
+		{"wrapped mutation failed", fmt.Errorf("labeling INT-1: %w", mutationFailed), false, true, true},
+		{"invalid query", &APIError{Kind: KindValidation, StatusCode: http.StatusBadRequest, GraphQLErrors: []*GraphQLError{invalid}, Err: invalid}, false, false, false},
+		{"unauthorized", &APIError{Kind: KindAuth, StatusCode: http.StatusUnauthorized, Err: errors.New("unexpected status 401")}, false, false, false},
+		{"server error", &APIError{Kind: KindServer, StatusCode: http.StatusBadGateway, Err: errors.New("unexpected status 502")}, false, false, false},
+		{"other error", errors.New("boom"), false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, ErrNotFound); got != tt.wantNotFound {
+				t.Errorf("errors.Is(%v, ErrNotFound) = %t, want %t", tt.err, got, tt.wantNotFound)
+			}
+			var mutationErr *MutationFailedError
+			if got := errors.As(tt.err, &mutationErr); got != tt.wantMutationFailed {
+				t.Errorf("errors.As(%v, *MutationFailedError) = %t, want %t", tt.err, got, tt.wantMutationFailed)
+			}
+			if got := Skippable(tt.err); got != tt.wantSkippable {
+				t.Errorf("Skippable(%v) = %t, want %t", tt.err, got, tt.wantSkippable)
+			}
+		})
+	}
+}
+
+func TestAPIErrorIs(t *testing.T) {
+	sentinels := []error{ErrNetwork, ErrRateLimited, ErrServer, ErrValidation, ErrUnauthorized}
+	kinds := []ErrorKind{KindNetwork, KindRateLimited, KindServer, KindValidation, KindAuth}
+	for i, kind := range kinds {
+		err := fmt.Errorf("wrapped: %w", &APIError{Kind: kind, Err: errors.New("failed")})
+		for j, sentinel := range sentinels {
+			if got := errors.Is(err, sentinel); got != (i == j) {
+				t.Errorf("errors.Is(%s, %v) = %t, want %t", kind, sentinel, got, i == j)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package linear
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DefaultPageSize is how many issues are loaded by each request when the query does not set a page size
+const DefaultPageSize = 50
+
+// IssueQuery selects the issues of a team. Each of the other fields is optional, and narrows the issues to those
+// which match it. The issues are filtered by Linear, so those which do not match are never downloaded.
+type IssueQuery struct {
+	TeamID string
+	// States are the names of the states to include
+	States []string
+	// ExcludeStates are the names of states, and ExcludeStateTypes the types of states, to leave out
+	ExcludeStates     []string
+	ExcludeStateTypes []string
+	// Labels are the names of labels, at least one of which the issue must have
+	Labels []string
+	// UpdatedAfter includes only the issues updated since the time
+	UpdatedAfter time.Time
+	// AssigneeID is the ID of the user the issues are assigned to
+	AssigneeID string
+	// PageSize is how many issues are loaded by each request, defaulting to DefaultPageSize
+	PageSize int
+}
+
+// Issues calls fn with each issue matching the query, loading the issues a page at a time. If fn returns an error,
+// the iteration stops and the error is returned.
+func (lc *LinearClient) Issues(ctx context.Context, q IssueQuery, fn func(issue *IssueNode) error) error {
+	if q.TeamID == "" {
+		return fmt.Errorf("issue query requires a team")
+	}
+	pageSize := q.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	filter := q.filter()
+	return Paginate(ctx, "", func(ctx context.Context, after string) (PageInfo, error) {
+		log.Printf("Loading issues for team %s after cursor %q\n", q.TeamID, after)
+		response, err := lc.GetIssuesForTeam(ctx, q.TeamID, filter, pageSize, after)
+		if err != nil {
+			return PageInfo{}, err
+		}
+
+		edges := response.Team.Issues.Edges
+		for i := range edges {
+			if err := fn(&edges[i].IssueNode); err != nil {
+				return PageInfo{}, err
+			}
+		}
+
+		return response.Team.Issues.PageInfo, nil
+	})
+}
+
+// filter converts the query into the filter Linear applies on the server, or nil if it selects every issue
+func (q *IssueQuery) filter() *IssueFilter {
+	var filter IssueFilter
+	var filtered bool
+
+	if len(q.States) > 0 || len(q.ExcludeStates) > 0 || len(q.ExcludeStateTypes) > 0 {
+		filter.State = &StateFilter{}
+		if len(q.States) > 0 || len(q.ExcludeStates) > 0 {
+			filter.State.Name = &StringComparator{In: q.States, Nin: q.ExcludeStates}
+		}
+		if len(q.ExcludeStateTypes) > 0 {
+			filter.State.Type = &StringComparator{Nin: q.ExcludeStateTypes}
+		}
+		filtered = true
+	}
+	if len(q.Labels) > 0 {
+		filter.Labels = &LabelsFilter{Name: &StringComparator{In: q.Labels}}
+		filtered = true
+	}
+	if !q.UpdatedAfter.IsZero() {
+		updatedAfter := q.UpdatedAfter
+		filter.UpdatedAt = &DateComparator{Gt: &updatedAfter}
+		filtered = true
+	}
+	if q.AssigneeID != "" {
+		filter.Assignee = &IDFilter{ID: &StringComparator{Eq: q.AssigneeID}}
+		filtered = true
+	}
+
+	if !filtered {
+		return nil
+	}
+	return &filter
+}
@@ -9,12 +9,8 @@ import (
 	"github.com/machinebox/graphql"
 )
 
-type LinearClient struct {
-	Token string
-}
-
-func (lc *LinearClient) FindLabelIDWithName(teamID string, labelName string) (string, error) {
-	labels, err := lc.getTeamLabels(teamID)
+func (lc *LinearClient) FindLabelIDWithName(ctx context.Context, teamID string, labelName string) (string, error) {
+	labels, err := lc.GetTeamLabels(ctx, teamID)
 	if err != nil {
 		return "", err
 	}
@@ -25,109 +21,279 @@ func (lc *LinearClient) FindLabelIDWithName(teamID string, labelName string) (st
 		}
 	}
 
-	return "", fmt.Errorf("cannot find label with name %s", labelName)
+	return "", fmt.Errorf("cannot find label with name %s: %w", labelName, ErrNotFound)
 }
 
-func (lc *LinearClient) GetIssuesForTeam(teamID string, pagination string) (*TeamIssuesResponse, error) {
-	query := fmt.Sprintf(issuesQuery, teamID, pagination)
+func (lc *LinearClient) ListTeams(ctx context.Context) ([]TeamNode, error) {
+	teams := make([]TeamNode, 0)
+	err := Paginate(ctx, "", func(ctx context.Context, after string) (PageInfo, error) {
+		req := graphql.NewRequest(teamsQuery)
+		req.Var("first", nestedPageSize)
+		if after != "" {
+			req.Var("after", after)
+		}
+
+		var response TeamsResponse
+		if err := lc.exectueQuery(ctx, req, &response); err != nil {
+			return PageInfo{}, err
+		}
+		teams = append(teams, response.Teams.Nodes...)
+		return response.Teams.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return teams, nil
+}
+
+// FindTeam finds the team with the given name or key (e.g. "Integrations-Cases" or "INT"), ignoring case. The team's
+// ID is also accepted, so that configs which use IDs keep working.
+func (lc *LinearClient) FindTeam(ctx context.Context, nameOrKey string) (*TeamNode, error) {
+	teams, err := lc.ListTeams(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range teams {
+		if t.ID == nameOrKey || strings.EqualFold(t.Name, nameOrKey) || strings.EqualFold(t.Key, nameOrKey) {
+			return &t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("cannot find team with name or key %s: %w", nameOrKey, ErrNotFound)
+}
+
+// GetIssuesForTeam loads a page of the team's issues which match the filter, which may be nil. Pass the end cursor of
+// the previous page as after, or an empty string for the first page.
+func (lc *LinearClient) GetIssuesForTeam(ctx context.Context, teamID string, filter *IssueFilter, first int, after string) (*TeamIssuesResponse, error) {
+	req := graphql.NewRequest(issuesQuery)
+	req.Var("teamId", teamID)
+	if filter != nil {
+		req.Var("filter", filter)
+	}
+	req.Var("first", first)
+	if after != "" {
+		req.Var("after", after)
+	}
 
 	var response TeamIssuesResponse
-	if err := lc.exectueQuery(query, &response); err != nil {
+	if err := lc.exectueQuery(ctx, req, &response); err != nil {
 		return nil, err
 	}
 
+	// load the rest of any long history or comments, so that SLAs are not measured from truncated history
+	edges := response.Team.Issues.Edges
+	for i := range edges {
+		if err := lc.completeIssue(ctx, &edges[i].IssueNode); err != nil {
+			return nil, err
+		}
+	}
+
 	return &response, nil
 }
 
-func (lc *LinearClient) AddLabelToTicket(ticketNumber string, labelID string) (bool, error) {
-	// get current set of labels
-	labels, err := lc.getLabels(ticketNumber)
-	if err != nil {
+// GetIssue loads the issue with the given ID or ticket number, e.g. INT-123, with all of its history and comments
+func (lc *LinearClient) GetIssue(ctx context.Context, id string) (*IssueNode, error) {
+	req := graphql.NewRequest(issueQuery)
+	req.Var("id", id)
+
+	var response IssueResponse
+	if err := lc.exectueQuery(ctx, req, &response); err != nil {
+		return nil, err
+	}
+	if err := lc.completeIssue(ctx, &response.Issue); err != nil {
+		return nil, err
+	}
+
+	return &response.Issue, nil
+}
+
+// AddLabelToTicket adds the label to the issue, unless it is among the labels loaded with the issue, and records it
+// on the issue. Only the one label is added, so labels added by anyone else in the meantime are kept.
+func (lc *LinearClient) AddLabelToTicket(ctx context.Context, issue *IssueNode, label IssueLabelNode) (bool, error) {
+	// if this label already exists, do not add it again
+	if TicketHasLabel(issue, label.ID) {
+		return false, nil
+	}
+
+	ticketNumber := TicketNumber(issue)
+	if err := lc.mutate(ctx, issueLabelOperation("issueAddLabel", ticketNumber, label.ID)); err != nil {
 		return false, err
 	}
+	issue.IssueLabels.Nodes = append(issue.IssueLabels.Nodes, label)
 
-	// get the labelIDs from the labels
-	labelIDs := make([]string, 0)
-	for _, l := range labels {
+	return true, nil
+}
 
-		// if this label already exists, do not add it again
-		if l.ID == labelID {
-			return false, nil
+// AddCommentToTicket posts the comment to the issue
+func (lc *LinearClient) AddCommentToTicket(ctx context.Context, issue *IssueNode, comment string) error {
+	return lc.mutate(ctx, commentCreateOperation(TicketNumber(issue), CommentCreateInput{
+		IssueID: issue.ID,
+		Body:    comment,
+	}))
+}
+
+// RemoveLabelFromTicket removes the label from the issue, if it is among the labels loaded with the issue, and from
+// the labels recorded on the issue. Only the one label is removed, so labels added by anyone else in the meantime are
+// kept.
+func (lc *LinearClient) RemoveLabelFromTicket(ctx context.Context, issue *IssueNode, labelID string) (bool, error) {
+	// no need to remove the label if it wasn't on the issue
+	if !TicketHasLabel(issue, labelID) {
+		return false, nil
+	}
+
+	ticketNumber := TicketNumber(issue)
+	if err := lc.mutate(ctx, issueLabelOperation("issueRemoveLabel", ticketNumber, labelID)); err != nil {
+		return false, err
+	}
+
+	remaining := make([]IssueLabelNode, 0, len(issue.IssueLabels.Nodes))
+	for _, l := range issue.IssueLabels.Nodes {
+		if l.ID != labelID {
+			remaining = append(remaining, l)
 		}
-		labelIDs = append(labelIDs, l.ID)
 	}
+	issue.IssueLabels.Nodes = remaining
+
+	return true, nil
+}
 
-	// add the new label to the list
-	labelIDs = append(labelIDs, labelID)
+// AddSubscriberToTicket subscribes the user to the issue, unless they are among the subscribers loaded with the issue,
+// and records them on the issue. Only the one user is subscribed, so subscribers added by anyone else in the meantime
+// are kept.
+func (lc *LinearClient) AddSubscriberToTicket(ctx context.Context, issue *IssueNode, userID string) (bool, error) {
+	// if this user is already subscribed, do not add them again
+	for _, u := range issue.Subscribers.Nodes {
+		if u.ID == userID {
+			return false, nil
+		}
+	}
 
-	// apply the labels
-	fmt.Printf("Adding label to ticket: %s\n", ticketNumber)
-	if err := lc.applyLabels(ticketNumber, labelIDs); err != nil {
+	if err := lc.mutate(ctx, issueSubscribeOperation(TicketNumber(issue), userID)); err != nil {
 		return false, err
 	}
+	issue.Subscribers.Nodes = append(issue.Subscribers.Nodes, UserNode{ID: userID})
 
 	return true, nil
 }
 
-func (lc *LinearClient) AddCommentToTicket(ticketID string, comment string) error {
-	mutation := fmt.Sprintf(addIssueCommentMutation, ticketID, comment)
+func (lc *LinearClient) SetAssignee(ctx context.Context, ticketNumber string, userID string) error {
+	return lc.updateIssue(ctx, ticketNumber, IssueUpdateInput{AssigneeID: userID})
+}
+
+func (lc *LinearClient) SetState(ctx context.Context, ticketNumber string, stateID string) error {
+	return lc.updateIssue(ctx, ticketNumber, IssueUpdateInput{StateID: stateID})
+}
 
-	var response CommentCreateResponse
-	err := lc.exectueQuery(mutation, &response)
-	if err != nil {
-		return err
-	}
+func (lc *LinearClient) SetPriority(ctx context.Context, ticketNumber string, priority int) error {
+	return lc.updateIssue(ctx, ticketNumber, IssueUpdateInput{Priority: &priority})
+}
+
+// SetDueDate sets the due date of the ticket, which is a date without a time (YYYY-MM-DD)
+func (lc *LinearClient) SetDueDate(ctx context.Context, ticketNumber string, dueDate string) error {
+	return lc.updateIssue(ctx, ticketNumber, IssueUpdateInput{DueDate: dueDate})
+}
 
-	if !response.CommentCreate.Success {
-		return fmt.Errorf("Adding comment did not succeed for ticket with ID %s", ticketID)
+// Viewer returns the user the client's token belongs to
+func (lc *LinearClient) Viewer(ctx context.Context) (*UserNode, error) {
+	var response ViewerResponse
+	if err := lc.exectueQuery(ctx, graphql.NewRequest(viewerQuery), &response); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &response.Viewer, nil
 }
 
-func (lc *LinearClient) RemoveLabelFromTicket(ticketNumber string, labelID string) error {
-	// get current set of labels
-	labels, err := lc.getLabels(ticketNumber)
+// FindUserID finds the ID of the user with the given name, display name, or email
+func (lc *LinearClient) FindUserID(ctx context.Context, user string) (string, error) {
+	var userID string
+	err := Paginate(ctx, "", func(ctx context.Context, after string) (PageInfo, error) {
+		req := graphql.NewRequest(usersQuery)
+		req.Var("first", nestedPageSize)
+		if after != "" {
+			req.Var("after", after)
+		}
+
+		var response UsersResponse
+		if err := lc.exectueQuery(ctx, req, &response); err != nil {
+			return PageInfo{}, err
+		}
+		for _, u := range response.Users.Nodes {
+			if u.Name == user || u.DisplayName == user || u.Email == user {
+				// stop at the page with the user
+				userID = u.ID
+				return PageInfo{}, nil
+			}
+		}
+		return response.Users.PageInfo, nil
+	})
 	if err != nil {
-		return err
+		return "", err
+	}
+	if userID == "" {
+		return "", fmt.Errorf("cannot find user with name %s: %w", user, ErrNotFound)
 	}
 
-	// get the labelIDs from the labels
-	labelIDs := make([]string, 0)
-	var foundLabel bool
-	for _, l := range labels {
-		// do not add the label that we are removing to the list
-		if l.ID == labelID {
-			foundLabel = true
-		} else {
-			labelIDs = append(labelIDs, l.ID)
+	return userID, nil
+}
+
+// GetWorkflowStates returns the team's workflow states
+func (lc *LinearClient) GetWorkflowStates(ctx context.Context, teamID string) ([]State, error) {
+	states := make([]State, 0)
+	err := Paginate(ctx, "", func(ctx context.Context, after string) (PageInfo, error) {
+		req := graphql.NewRequest(workflowStatesQuery)
+		req.Var("teamId", teamID)
+		req.Var("first", nestedPageSize)
+		if after != "" {
+			req.Var("after", after)
+		}
+
+		var response TeamStatesResponse
+		if err := lc.exectueQuery(ctx, req, &response); err != nil {
+			return PageInfo{}, err
 		}
+		states = append(states, response.Team.States.Nodes...)
+		return response.Team.States.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if foundLabel { // no need to apply the labels if it wasn't in the list
-		// apply the labels
-		fmt.Printf("Found label, removing from ticket %s\n", ticketNumber)
-		return lc.applyLabels(ticketNumber, labelIDs)
+	return states, nil
+}
+
+func (lc *LinearClient) FindWorkflowStateIDWithName(ctx context.Context, teamID string, stateName string) (string, error) {
+	states, err := lc.GetWorkflowStates(ctx, teamID)
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	for _, s := range states {
+		if s.Name == stateName {
+			return s.ID, nil
+		}
+	}
+
+	return "", fmt.Errorf("cannot find workflow state with name %s: %w", stateName, ErrNotFound)
 }
 
 func TicketNumber(issue *IssueNode) string {
 	return fmt.Sprintf("%s-%d", issue.TeamName.Key, issue.Number)
 }
 
-func (lc *LinearClient) GetLastTimeIssueWasCommentedOn(issue *IssueNode, ignoreCommentsByUserWithName string) (time.Time, error) {
-	ticketNumber := TicketNumber(issue)
-	comments, err := lc.getIssueComments(ticketNumber)
-	if err != nil {
-		return time.Time{}, err
+// GetLastTimeIssueWasCommentedOn returns when the issue was last commented on by anyone other than the ignored users,
+// or the zero time if it never has been
+func GetLastTimeIssueWasCommentedOn(issue *IssueNode, ignoreUserIDs []string) time.Time {
+	ignored := make(map[string]bool, len(ignoreUserIDs))
+	for _, id := range ignoreUserIDs {
+		ignored[id] = true
 	}
 
 	lastCommentTime := time.Time{}
-	for _, c := range comments {
+	for _, c := range issue.IssueComments.Nodes {
 		// We want to ignore comments made by the auto-labeler itself, so that we do not use that as part of the criteria when determining the last time a comment was made
-		if c.User.Name == ignoreCommentsByUserWithName {
+		if ignored[c.User.ID] {
 			continue
 		}
 
@@ -136,7 +302,7 @@ func (lc *LinearClient) GetLastTimeIssueWasCommentedOn(issue *IssueNode, ignoreC
 		}
 	}
 
-	return lastCommentTime, nil
+	return lastCommentTime
 }
 
 func GetLastTimeIssueEnteredState(issue *IssueNode, state string) time.Time {
@@ -154,91 +320,110 @@ func GetLastTimeIssueEnteredState(issue *IssueNode, state string) time.Time {
 	return timeEnteredState
 }
 
-func (lc *LinearClient) getLabels(ticketNumber string) ([]IssueLabelNode, error) {
-	query := fmt.Sprintf(issueLabelsQuery, ticketNumber)
+// GetLastTimeIssueEnteredStateType returns the last time the issue entered any state of the given type, or when it
+// was created if it never has
+func GetLastTimeIssueEnteredStateType(issue *IssueNode, stateType string) time.Time {
+	timeEnteredState := issue.CreatedAt
 
-	var response IssueResponse
-	err := lc.exectueQuery(query, &response)
-	if err != nil {
-		return nil, err
+	for _, history := range issue.IssueHistory.Nodes {
+		if strings.EqualFold(history.ToState.Type, stateType) {
+			if history.CreatedAt.After(timeEnteredState) {
+				timeEnteredState = history.CreatedAt
+			}
+		}
 	}
 
-	return response.Issue.IssueLabels.Nodes, nil
+	return timeEnteredState
 }
 
-func (lc *LinearClient) TicketHasLabel(ticketNumber, labelID string) (bool, error) {
-	labels, err := lc.getLabels(ticketNumber)
-	if err != nil {
-		return false, err
-	}
-	for _, l := range labels {
+// TicketHasLabel returns true if the label is among the labels loaded with the issue
+func TicketHasLabel(issue *IssueNode, labelID string) bool {
+	for _, l := range issue.IssueLabels.Nodes {
 		if l.ID == labelID {
-			return true, nil
+			return true
 		}
 	}
-	return false, nil
+	return false
 }
 
-func (lc *LinearClient) exectueQuery(query string, response interface{}) error {
-	graphqlClient := graphql.NewClient("https://api.linear.app/graphql") // TODO only do this once in the client itself
-	graphqlRequest := graphql.NewRequest(query)
-
-	headers := make(map[string][]string)
-	headers["Authorization"] = []string{lc.Token}
-
-	graphqlRequest.Header = headers
-
-	if err := graphqlClient.Run(context.Background(), graphqlRequest, &response); err != nil {
-		return err
-	}
-
-	return nil
+func getTimeIssueEnteredCurrentState(issue *IssueNode) time.Time {
+	return GetLastTimeIssueEnteredState(issue, issue.State.Name)
 }
 
-func (lc *LinearClient) getIssueComments(ticketNumber string) ([]IssueCommentNode, error) {
-	query := fmt.Sprintf(issueCommentsQuery, ticketNumber)
+// GetTeamLabels returns the labels which can be used on the team's issues
+func (lc *LinearClient) GetTeamLabels(ctx context.Context, teamID string) ([]IssueLabelNode, error) {
+	labels := make([]IssueLabelNode, 0)
+	err := Paginate(ctx, "", func(ctx context.Context, after string) (PageInfo, error) {
+		req := graphql.NewRequest(labelsQuery)
+		req.Var("teamId", teamID)
+		req.Var("first", nestedPageSize)
+		if after != "" {
+			req.Var("after", after)
+		}
 
-	var response IssueResponse
-	if err := lc.exectueQuery(query, &response); err != nil {
+		var response TeamLabelsResponse
+		if err := lc.exectueQuery(ctx, req, &response); err != nil {
+			return PageInfo{}, err
+		}
+		labels = append(labels, response.TeamLabels.IssueLabels.Nodes...)
+		return response.TeamLabels.IssueLabels.PageInfo, nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	return response.Issue.IssueComments.Nodes, nil
-}
+	if lc.dryRun != nil {
+		labels = append(labels, lc.dryRun.createdLabels()...)
+	}
 
-func getTimeIssueEnteredCurrentState(issue *IssueNode) time.Time {
-	return GetLastTimeIssueEnteredState(issue, issue.State.Name)
+	return labels, nil
 }
 
-func (lc *LinearClient) getTeamLabels(teamID string) ([]IssueLabelNode, error) {
-	query := fmt.Sprintf(labelsQuery, teamID)
+// CreateLabel creates a label, returning the new label
+func (lc *LinearClient) CreateLabel(ctx context.Context, input LabelCreateInput) (*IssueLabelNode, error) {
+	if lc.dryRun != nil {
+		return lc.dryRun.createLabel(input), nil
+	}
+
+	req := graphql.NewRequest(labelCreateMutation)
+	req.Var("input", input)
 
-	var response TeamLabelsResponse
-	err := lc.exectueQuery(query, &response)
+	var response LabelCreateResponse
+	err := lc.executeMutation(ctx, req, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	return response.TeamLabels.IssueLabels.Nodes, nil
+	if !response.IssueLabelCreate.Success {
+		return nil, &MutationFailedError{Mutation: "issueLabelCreate", ID: input.Name}
+	}
+
+	return &response.IssueLabelCreate.IssueLabel, nil
 }
 
-func (lc *LinearClient) applyLabels(ticketNumber string, labelIDs []string) error {
-	var labelIDsString string
-	if len(labelIDs) > 0 {
-		labelIDsString = strings.Join(labelIDs, `", "`)
-		labelIDsString = fmt.Sprintf(`"%s"`, labelIDsString) // need this format: "id1", "id2", "id3"
+// UpdateLabel updates the color, description or parent of a label
+func (lc *LinearClient) UpdateLabel(ctx context.Context, labelID string, input LabelUpdateInput) error {
+	if lc.dryRun != nil {
+		return nil
 	}
-	mutation := fmt.Sprintf(addIssueLabelMutation, ticketNumber, labelIDsString)
 
-	var response IssueUpdateResponse
-	err := lc.exectueQuery(mutation, &response)
+	req := graphql.NewRequest(labelUpdateMutation)
+	req.Var("id", labelID)
+	req.Var("input", input)
+
+	var response LabelUpdateResponse
+	err := lc.executeMutation(ctx, req, &response)
 	if err != nil {
 		return err
 	}
 
-	if !response.IssueUpdate.Success {
-		return fmt.Errorf("Applying labels did not succeed for ticket %s", ticketNumber)
+	if !response.IssueLabelUpdate.Success {
+		return &MutationFailedError{Mutation: "issueLabelUpdate", ID: labelID}
 	}
 
 	return nil
 }
+
+func (lc *LinearClient) updateIssue(ctx context.Context, ticketNumber string, input IssueUpdateInput) error {
+	return lc.mutate(ctx, issueUpdateOperation(ticketNumber, input))
+}
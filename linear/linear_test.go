@@ -0,0 +1,49 @@
+package linear_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jmartin127/linear-autolabeler/linear"
+	"github.com/jmartin127/linear-autolabeler/linear/lineartest"
+)
+
+func TestListsArePaged(t *testing.T) {
+	srv := lineartest.NewServer()
+	t.Cleanup(srv.Close)
+
+	// more than fit in a page
+	const n = 120
+	var team *lineartest.Team
+	for i := 1; i <= n; i++ {
+		team = srv.AddTeam(fmt.Sprintf("T%d", i), fmt.Sprintf("Team %d", i))
+	}
+	for i := 1; i <= n; i++ {
+		srv.AddState(team, fmt.Sprintf("State %d", i), linear.StateTypeStarted)
+	}
+	lc := srv.Client()
+	ctx := context.Background()
+
+	teams, err := lc.ListTeams(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(teams) != n {
+		t.Errorf("ListTeams() returned %d teams, want %d", len(teams), n)
+	}
+	if found, err := lc.FindTeam(ctx, fmt.Sprintf("T%d", n)); err != nil || found.ID != team.ID {
+		t.Errorf("FindTeam() = %v, %v, want the last team", found, err)
+	}
+
+	states, err := lc.GetWorkflowStates(ctx, team.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != n {
+		t.Errorf("GetWorkflowStates() returned %d states, want %d", len(states), n)
+	}
+	if id, err := lc.FindWorkflowStateIDWithName(ctx, team.ID, fmt.Sprintf("State %d", n)); err != nil || id == "" {
+		t.Errorf("FindWorkflowStateIDWithName() = %q, %v, want the last state", id, err)
+	}
+}
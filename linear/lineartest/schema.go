@@ -0,0 +1,824 @@
+package lineartest
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// newSchema builds the subset of the Linear schema served by the fake. Resolvers run with the server's lock held.
+func newSchema(s *Server) (graphql.Schema, error) {
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name":        &graphql.Field{Type: graphql.String},
+			"displayName": &graphql.Field{Type: graphql.String},
+			"email":       &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	stateType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "WorkflowState",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name": &graphql.Field{Type: graphql.String},
+			"type": &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	labelType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "IssueLabel",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"name":        &graphql.Field{Type: graphql.String},
+			"color":       &graphql.Field{Type: graphql.String},
+			"description": &graphql.Field{Type: graphql.String},
+		},
+	})
+	labelType.AddFieldConfig("parent", &graphql.Field{
+		Type: labelType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return nullable(s.findLabel(p.Source.(*Label).ParentID)), nil
+		},
+	})
+
+	commentType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Comment",
+		Fields: graphql.Fields{
+			"createdAt": &graphql.Field{Type: graphql.DateTime},
+			"body":      &graphql.Field{Type: graphql.String},
+			"user": &graphql.Field{
+				Type: userType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nullable(s.findUser(p.Source.(*Comment).UserID)), nil
+				},
+			},
+		},
+	})
+
+	historyType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "IssueHistory",
+		Fields: graphql.Fields{
+			"createdAt": &graphql.Field{Type: graphql.DateTime},
+			"fromState": &graphql.Field{
+				Type: stateType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nullable(s.findState(p.Source.(*HistoryEntry).FromStateID)), nil
+				},
+			},
+			"toState": &graphql.Field{
+				Type: stateType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return nullable(s.findState(p.Source.(*HistoryEntry).ToStateID)), nil
+				},
+			},
+		},
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	stateConnection := pagedConnection("WorkflowStateConnection", stateType, pageInfoType)
+	userConnection := pagedConnection("UserConnection", userType, pageInfoType)
+	labelConnection := pagedConnection("IssueLabelConnection", labelType, pageInfoType)
+	commentConnection := pagedConnection("CommentConnection", commentType, pageInfoType)
+	historyConnection := pagedConnection("IssueHistoryConnection", historyType, pageInfoType)
+	pageArgs := graphql.FieldConfigArgument{
+		"first": &graphql.ArgumentConfig{Type: graphql.Int},
+		"after": &graphql.ArgumentConfig{Type: graphql.String},
+	}
+
+	stringComparator := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "StringComparator",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"eq":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"in":  &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+			"nin": &graphql.InputObjectFieldConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.String))},
+		},
+	})
+	issueFilter := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "IssueFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"state": &graphql.InputObjectFieldConfig{Type: graphql.NewInputObject(graphql.InputObjectConfig{
+				Name: "WorkflowStateFilter",
+				Fields: graphql.InputObjectConfigFieldMap{
+					"name": &graphql.InputObjectFieldConfig{Type: stringComparator},
+					"type": &graphql.InputObjectFieldConfig{Type: stringComparator},
+				},
+			})},
+			"labels": &graphql.InputObjectFieldConfig{Type: graphql.NewInputObject(graphql.InputObjectConfig{
+				Name: "IssueLabelCollectionFilter",
+				Fields: graphql.InputObjectConfigFieldMap{
+					"name": &graphql.InputObjectFieldConfig{Type: stringComparator},
+				},
+			})},
+			"updatedAt": &graphql.InputObjectFieldConfig{Type: graphql.NewInputObject(graphql.InputObjectConfig{
+				Name: "DateComparator",
+				Fields: graphql.InputObjectConfigFieldMap{
+					"gt": &graphql.InputObjectFieldConfig{Type: graphql.DateTime},
+				},
+			})},
+			"assignee": &graphql.InputObjectFieldConfig{Type: graphql.NewInputObject(graphql.InputObjectConfig{
+				Name: "NullableUserFilter",
+				Fields: graphql.InputObjectConfigFieldMap{
+					"id": &graphql.InputObjectFieldConfig{Type: stringComparator},
+				},
+			})},
+		},
+	})
+
+	var teamType *graphql.Object
+	issueType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Issue",
+		Fields: (graphql.FieldsThunk)(func() graphql.Fields {
+			return graphql.Fields{
+				"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"number":      &graphql.Field{Type: graphql.Int},
+				"title":       &graphql.Field{Type: graphql.String},
+				"description": &graphql.Field{Type: graphql.String},
+				"priority":    &graphql.Field{Type: graphql.Int},
+				"createdAt":   &graphql.Field{Type: graphql.DateTime},
+				"updatedAt":   &graphql.Field{Type: graphql.DateTime},
+				"identifier": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return s.identifier(p.Source.(*Issue)), nil
+					},
+				},
+				"dueDate": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if d := p.Source.(*Issue).DueDate; d != "" {
+							return d, nil
+						}
+						return nil, nil
+					},
+				},
+				"assignee": &graphql.Field{
+					Type: userType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return nullable(s.findUser(p.Source.(*Issue).AssigneeID)), nil
+					},
+				},
+				"state": &graphql.Field{
+					Type: stateType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return nullable(s.findState(p.Source.(*Issue).StateID)), nil
+					},
+				},
+				"team": &graphql.Field{
+					Type: teamType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return nullable(s.findTeam(p.Source.(*Issue).TeamID)), nil
+					},
+				},
+				"labels": &graphql.Field{
+					Type: labelConnection,
+					Args: pageArgs,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						nodes := make([]interface{}, 0)
+						for _, id := range p.Source.(*Issue).LabelIDs {
+							if l := s.findLabel(id); l != nil {
+								nodes = append(nodes, l)
+							}
+						}
+						return nodePage(nodes, p.Args)
+					},
+				},
+				"subscribers": &graphql.Field{
+					Type: userConnection,
+					Args: pageArgs,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						nodes := make([]interface{}, 0)
+						for _, id := range p.Source.(*Issue).SubscriberIDs {
+							if u := s.findUser(id); u != nil {
+								nodes = append(nodes, u)
+							}
+						}
+						return nodePage(nodes, p.Args)
+					},
+				},
+				"comments": &graphql.Field{
+					Type: commentConnection,
+					Args: pageArgs,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						nodes := make([]interface{}, 0)
+						for _, c := range p.Source.(*Issue).Comments {
+							nodes = append(nodes, c)
+						}
+						return nodePage(nodes, p.Args)
+					},
+				},
+				"history": &graphql.Field{
+					Type: historyConnection,
+					Args: pageArgs,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						// Linear returns the most recent history first
+						history := p.Source.(*Issue).History
+						nodes := make([]interface{}, 0, len(history))
+						for i := len(history) - 1; i >= 0; i-- {
+							nodes = append(nodes, history[i])
+						}
+						return nodePage(nodes, p.Args)
+					},
+				},
+			}
+		}),
+	})
+
+	issueEdgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "IssueEdge",
+		Fields: graphql.Fields{
+			"node":   &graphql.Field{Type: issueType},
+			"cursor": &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	issueConnection := graphql.NewObject(graphql.ObjectConfig{
+		Name: "IssueConnection",
+		Fields: graphql.Fields{
+			"edges":    &graphql.Field{Type: graphql.NewList(issueEdgeType)},
+			"nodes":    &graphql.Field{Type: graphql.NewList(issueType)},
+			"pageInfo": &graphql.Field{Type: pageInfoType},
+		},
+	})
+
+	teamType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Team",
+		Fields: graphql.Fields{
+			"id":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"key":  &graphql.Field{Type: graphql.String},
+			"name": &graphql.Field{Type: graphql.String},
+			"issues": &graphql.Field{
+				Type: issueConnection,
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: issueFilter},
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					team := p.Source.(*Team)
+					filter, _ := p.Args["filter"].(map[string]interface{})
+					issues := make([]*Issue, 0)
+					for _, i := range s.issues {
+						if i.TeamID == team.ID && s.issueMatches(i, filter) {
+							issues = append(issues, i)
+						}
+					}
+					return s.issuePage(issues, p.Args)
+				},
+			},
+			"labels": &graphql.Field{
+				Type: labelConnection,
+				Args: pageArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					nodes := make([]interface{}, 0)
+					for _, l := range s.labels {
+						if l.TeamID == p.Source.(*Team).ID {
+							nodes = append(nodes, l)
+						}
+					}
+					return nodePage(nodes, p.Args)
+				},
+			},
+			"states": &graphql.Field{
+				Type: stateConnection,
+				Args: pageArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					nodes := make([]interface{}, 0)
+					for _, st := range s.states {
+						if st.TeamID == p.Source.(*Team).ID {
+							nodes = append(nodes, st)
+						}
+					}
+					return nodePage(nodes, p.Args)
+				},
+			},
+		},
+	})
+	teamConnection := pagedConnection("TeamConnection", teamType, pageInfoType)
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"teams": &graphql.Field{
+				Type: teamConnection,
+				Args: pageArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					nodes := make([]interface{}, 0, len(s.teams))
+					for _, t := range s.teams {
+						nodes = append(nodes, t)
+					}
+					return nodePage(nodes, p.Args)
+				},
+			},
+			"team": &graphql.Field{
+				Type: teamType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					t := s.findTeam(p.Args["id"].(string))
+					if t == nil {
+						return nil, fmt.Errorf("Entity not found")
+					}
+					return t, nil
+				},
+			},
+			"issue": &graphql.Field{
+				Type: issueType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					i := s.findIssue(p.Args["id"].(string))
+					if i == nil {
+						return nil, fmt.Errorf("Entity not found")
+					}
+					return i, nil
+				},
+			},
+			"viewer": &graphql.Field{
+				Type: graphql.NewNonNull(userType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return s.Viewer, nil
+				},
+			},
+			"users": &graphql.Field{
+				Type: userConnection,
+				Args: pageArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					nodes := make([]interface{}, 0, len(s.users))
+					for _, u := range s.users {
+						nodes = append(nodes, u)
+					}
+					return nodePage(nodes, p.Args)
+				},
+			},
+			"workflowStates": &graphql.Field{
+				Type: stateConnection,
+				Args: pageArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					nodes := make([]interface{}, 0, len(s.states))
+					for _, st := range s.states {
+						nodes = append(nodes, st)
+					}
+					return nodePage(nodes, p.Args)
+				},
+			},
+		},
+	})
+
+	issuePayload := graphql.NewObject(graphql.ObjectConfig{
+		Name: "IssuePayload",
+		Fields: graphql.Fields{
+			"success": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"issue":   &graphql.Field{Type: issueType},
+		},
+	})
+
+	commentPayload := graphql.NewObject(graphql.ObjectConfig{
+		Name: "CommentPayload",
+		Fields: graphql.Fields{
+			"success": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"comment": &graphql.Field{Type: commentType},
+		},
+	})
+
+	issueUpdateInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "IssueUpdateInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"assigneeId": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"stateId":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"priority":   &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"dueDate":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	commentCreateInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CommentCreateInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"issueId": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"body":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	labelPayload := graphql.NewObject(graphql.ObjectConfig{
+		Name: "IssueLabelPayload",
+		Fields: graphql.Fields{
+			"success":    &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"issueLabel": &graphql.Field{Type: labelType},
+		},
+	})
+
+	labelCreateInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "IssueLabelCreateInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"teamId":      &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"name":        &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"color":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"parentId":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"isGroup":     &graphql.InputObjectFieldConfig{Type: graphql.Boolean},
+		},
+	})
+
+	labelUpdateInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "IssueLabelUpdateInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"color":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"description": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"parentId":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	labelArgs := graphql.FieldConfigArgument{
+		"id":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+		"labelId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+	}
+
+	// the payloads are non-null, as they are in Linear, so when one of several aliased mutations fails the error nulls
+	// the whole response, and the mutations after it are not run
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"issueUpdate": &graphql.Field{
+				Type: graphql.NewNonNull(issuePayload),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(issueUpdateInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					s.mutations = append(s.mutations, Mutation{Name: "issueUpdate", Args: p.Args})
+					i := s.findIssue(p.Args["id"].(string))
+					if i == nil {
+						return nil, fmt.Errorf("Entity not found")
+					}
+					s.updateIssue(i, p.Args["input"].(map[string]interface{}))
+					return map[string]interface{}{"success": true, "issue": i}, nil
+				},
+			},
+			"issueAddLabel": &graphql.Field{
+				Type: graphql.NewNonNull(issuePayload),
+				Args: labelArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					s.mutations = append(s.mutations, Mutation{Name: "issueAddLabel", Args: p.Args})
+					i, l := s.findIssue(p.Args["id"].(string)), s.findLabel(p.Args["labelId"].(string))
+					if i == nil || l == nil {
+						return nil, fmt.Errorf("Entity not found")
+					}
+					if !containsString(i.LabelIDs, l.ID) {
+						i.LabelIDs = append(i.LabelIDs, l.ID)
+					}
+					i.UpdatedAt = s.now
+					return map[string]interface{}{"success": true, "issue": i}, nil
+				},
+			},
+			"issueSubscribe": &graphql.Field{
+				Type: graphql.NewNonNull(issuePayload),
+				Args: graphql.FieldConfigArgument{
+					"id":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"userId": &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					s.mutations = append(s.mutations, Mutation{Name: "issueSubscribe", Args: p.Args})
+					// the viewer is subscribed when no user is given
+					userID := s.Viewer.ID
+					if id, ok := p.Args["userId"].(string); ok {
+						userID = id
+					}
+					i, u := s.findIssue(p.Args["id"].(string)), s.findUser(userID)
+					if i == nil || u == nil {
+						return nil, fmt.Errorf("Entity not found")
+					}
+					if !containsString(i.SubscriberIDs, u.ID) {
+						i.SubscriberIDs = append(i.SubscriberIDs, u.ID)
+					}
+					i.UpdatedAt = s.now
+					return map[string]interface{}{"success": true, "issue": i}, nil
+				},
+			},
+			"issueRemoveLabel": &graphql.Field{
+				Type: graphql.NewNonNull(issuePayload),
+				Args: labelArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					s.mutations = append(s.mutations, Mutation{Name: "issueRemoveLabel", Args: p.Args})
+					i := s.findIssue(p.Args["id"].(string))
+					if i == nil {
+						return nil, fmt.Errorf("Entity not found")
+					}
+					labelIDs := make([]string, 0, len(i.LabelIDs))
+					for _, id := range i.LabelIDs {
+						if id != p.Args["labelId"].(string) {
+							labelIDs = append(labelIDs, id)
+						}
+					}
+					i.LabelIDs = labelIDs
+					i.UpdatedAt = s.now
+					return map[string]interface{}{"success": true, "issue": i}, nil
+				},
+			},
+			"commentCreate": &graphql.Field{
+				Type: graphql.NewNonNull(commentPayload),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(commentCreateInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					s.mutations = append(s.mutations, Mutation{Name: "commentCreate", Args: p.Args})
+					input := p.Args["input"].(map[string]interface{})
+					i := s.findIssue(input["issueId"].(string))
+					if i == nil {
+						return nil, fmt.Errorf("Entity not found")
+					}
+					c := &Comment{CreatedAt: s.now, Body: input["body"].(string), UserID: s.Viewer.ID}
+					i.Comments = append(i.Comments, c)
+					i.UpdatedAt = s.now
+					return map[string]interface{}{"success": true, "comment": c}, nil
+				},
+			},
+			"issueLabelCreate": &graphql.Field{
+				Type: graphql.NewNonNull(labelPayload),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(labelCreateInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					s.mutations = append(s.mutations, Mutation{Name: "issueLabelCreate", Args: p.Args})
+					input := p.Args["input"].(map[string]interface{})
+					l := &Label{ID: s.newID("label"), Name: input["name"].(string)}
+					if teamID, ok := input["teamId"].(string); ok {
+						if s.findTeam(teamID) == nil {
+							return nil, fmt.Errorf("Entity not found")
+						}
+						l.TeamID = teamID
+					}
+					if isGroup, ok := input["isGroup"].(bool); ok {
+						l.IsGroup = isGroup
+					}
+					if err := s.updateLabel(l, input); err != nil {
+						return nil, err
+					}
+					s.labels = append(s.labels, l)
+					return map[string]interface{}{"success": true, "issueLabel": l}, nil
+				},
+			},
+			"issueLabelUpdate": &graphql.Field{
+				Type: graphql.NewNonNull(labelPayload),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(labelUpdateInput)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					s.mutations = append(s.mutations, Mutation{Name: "issueLabelUpdate", Args: p.Args})
+					l := s.findLabel(p.Args["id"].(string))
+					if l == nil {
+						return nil, fmt.Errorf("Entity not found")
+					}
+					if err := s.updateLabel(l, p.Args["input"].(map[string]interface{})); err != nil {
+						return nil, err
+					}
+					return map[string]interface{}{"success": true, "issueLabel": l}, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+// pagedConnection creates a connection type which supports listing a page of its nodes, along with the page info
+func pagedConnection(name string, nodeType, pageInfoType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name,
+		Fields: graphql.Fields{
+			"nodes":    &graphql.Field{Type: graphql.NewList(nodeType)},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+		},
+	})
+}
+
+// nodePage returns the page of nodes selected by the first and after arguments, for a paged connection. The cursor of
+// a node is its position.
+func nodePage(nodes []interface{}, args map[string]interface{}) (interface{}, error) {
+	start := 0
+	if after, ok := args["after"].(string); ok && after != "" {
+		idx, err := strconv.Atoi(after)
+		if err != nil || idx < 0 || idx >= len(nodes) {
+			return nil, fmt.Errorf("invalid cursor %s", after)
+		}
+		start = idx + 1
+	}
+
+	// Linear defaults to pages of 50
+	first := 50
+	if f, ok := args["first"].(int); ok {
+		first = f
+	}
+	end := start + first
+	if end > len(nodes) {
+		end = len(nodes)
+	}
+
+	var endCursor interface{}
+	if end > start {
+		endCursor = strconv.Itoa(end - 1)
+	}
+
+	return map[string]interface{}{
+		"nodes": nodes[start:end],
+		"pageInfo": map[string]interface{}{
+			"hasNextPage": end < len(nodes),
+			"endCursor":   endCursor,
+		},
+	}, nil
+}
+
+// issueMatches returns true if the issue matches the filter argument of an issues connection
+func (s *Server) issueMatches(i *Issue, filter map[string]interface{}) bool {
+	if state, ok := filter["state"].(map[string]interface{}); ok {
+		st := s.findState(i.StateID)
+		if st == nil || !compareString(st.Name, state["name"]) || !compareString(st.Type, state["type"]) {
+			return false
+		}
+	}
+	if labels, ok := filter["labels"].(map[string]interface{}); ok {
+		var some bool
+		for _, id := range i.LabelIDs {
+			if l := s.findLabel(id); l != nil && compareString(l.Name, labels["name"]) {
+				some = true
+			}
+		}
+		if !some {
+			return false
+		}
+	}
+	if updatedAt, ok := filter["updatedAt"].(map[string]interface{}); ok {
+		if gt, ok := updatedAt["gt"].(time.Time); ok && !i.UpdatedAt.After(gt) {
+			return false
+		}
+	}
+	if assignee, ok := filter["assignee"].(map[string]interface{}); ok {
+		if !compareString(i.AssigneeID, assignee["id"]) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareString returns true if the value matches the StringComparator, which may be nil
+func compareString(value string, comparator interface{}) bool {
+	c, ok := comparator.(map[string]interface{})
+	if !ok {
+		return true
+	}
+	if eq, ok := c["eq"].(string); ok && value != eq {
+		return false
+	}
+	if in, ok := c["in"].([]interface{}); ok && !containsString(toStrings(in), value) {
+		return false
+	}
+	if nin, ok := c["nin"].([]interface{}); ok && containsString(toStrings(nin), value) {
+		return false
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// issuePage returns the page of issues selected by the first and after arguments. Issues are ordered by creation,
+// and the cursor of an issue is its ID.
+func (s *Server) issuePage(issues []*Issue, args map[string]interface{}) (interface{}, error) {
+	sort.SliceStable(issues, func(a, b int) bool {
+		return issues[a].CreatedAt.Before(issues[b].CreatedAt)
+	})
+
+	start := 0
+	if after, ok := args["after"].(string); ok && after != "" {
+		start = -1
+		for idx, i := range issues {
+			if i.ID == after {
+				start = idx + 1
+				break
+			}
+		}
+		if start == -1 {
+			return nil, fmt.Errorf("invalid cursor %s", after)
+		}
+	}
+
+	// Linear defaults to pages of 50
+	first := 50
+	if f, ok := args["first"].(int); ok {
+		first = f
+	}
+	end := start + first
+	if end > len(issues) {
+		end = len(issues)
+	}
+
+	page := issues[start:end]
+	edges := make([]interface{}, 0, len(page))
+	nodes := make([]interface{}, 0, len(page))
+	var endCursor interface{}
+	for _, i := range page {
+		edges = append(edges, map[string]interface{}{"node": i, "cursor": i.ID})
+		nodes = append(nodes, i)
+		endCursor = i.ID
+	}
+
+	return map[string]interface{}{
+		"edges": edges,
+		"nodes": nodes,
+		"pageInfo": map[string]interface{}{
+			"hasNextPage": end < len(issues),
+			"endCursor":   endCursor,
+		},
+	}, nil
+}
+
+func (s *Server) updateIssue(i *Issue, input map[string]interface{}) {
+	i.UpdatedAt = s.now
+	if v, ok := input["assigneeId"].(string); ok {
+		i.AssigneeID = v
+	}
+	if v, ok := input["stateId"].(string); ok && v != i.StateID {
+		s.moveIssue(i, v)
+	}
+	if v, ok := input["priority"].(int); ok {
+		i.Priority = v
+	}
+	if v, ok := input["dueDate"].(string); ok {
+		i.DueDate = v
+	}
+}
+
+// updateLabel applies the fields of an issueLabelCreate or issueLabelUpdate input to the label
+func (s *Server) updateLabel(l *Label, input map[string]interface{}) error {
+	if v, ok := input["parentId"].(string); ok {
+		parent := s.findLabel(v)
+		if parent == nil {
+			return fmt.Errorf("Entity not found")
+		}
+		l.ParentID = parent.ID
+	}
+	if v, ok := input["name"].(string); ok {
+		l.Name = v
+	}
+	if v, ok := input["color"].(string); ok {
+		l.Color = v
+	}
+	if v, ok := input["description"].(string); ok {
+		l.Description = v
+	}
+	return nil
+}
+
+// nullable converts a nil pointer into a nil interface, so that it is returned as null
+func nullable(v interface{}) interface{} {
+	switch x := v.(type) {
+	case *User:
+		if x == nil {
+			return nil
+		}
+	case *State:
+		if x == nil {
+			return nil
+		}
+	case *Team:
+		if x == nil {
+			return nil
+		}
+	case *Label:
+		if x == nil {
+			return nil
+		}
+	}
+	return v
+}
+
+func toStrings(values []interface{}) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		result = append(result, v.(string))
+	}
+	return result
+}
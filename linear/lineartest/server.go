@@ -0,0 +1,408 @@
+// Package lineartest provides an in-memory fake of the parts of the Linear GraphQL API used by the linear package,
+// for use in tests.
+package lineartest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/jmartin127/linear-autolabeler/linear"
+)
+
+// Server is a fake Linear API. Tests seed it with teams, states, labels, users and issues, then point a
+// LinearClient at it and check the mutations it received.
+type Server struct {
+	*httptest.Server
+
+	// Viewer is the user the client is authenticated as, who authors the comments it creates
+	Viewer *User
+
+	mu        sync.Mutex
+	now       time.Time
+	nextID    int
+	teams     []*Team
+	users     []*User
+	labels    []*Label
+	states    []*State
+	issues    []*Issue
+	mutations []Mutation
+	failures  []failure
+	requests  int
+	schema    graphql.Schema
+}
+
+// failure is a response the server sends instead of processing a request
+type failure struct {
+	statusCode int
+	header     http.Header
+}
+
+type Team struct {
+	ID         string
+	Key        string
+	Name       string
+	nextNumber int
+}
+
+type User struct {
+	ID          string
+	Name        string
+	DisplayName string
+	Email       string
+}
+
+type Label struct {
+	ID          string
+	Name        string
+	Color       string
+	Description string
+	ParentID    string
+	IsGroup     bool
+	TeamID      string
+}
+
+type State struct {
+	ID     string
+	Name   string
+	Type   string
+	TeamID string
+}
+
+type Issue struct {
+	ID            string
+	TeamID        string
+	Number        int
+	Title         string
+	Description   string
+	Priority      int
+	DueDate       string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	AssigneeID    string
+	StateID       string
+	LabelIDs      []string
+	SubscriberIDs []string
+	Comments      []*Comment
+	History       []*HistoryEntry
+}
+
+type Comment struct {
+	CreatedAt time.Time
+	Body      string
+	UserID    string
+}
+
+type HistoryEntry struct {
+	CreatedAt   time.Time
+	FromStateID string
+	ToStateID   string
+}
+
+// Mutation is a mutation received by the server, e.g. issueUpdate with its id and input arguments
+type Mutation struct {
+	Name string
+	Args map[string]interface{}
+}
+
+// DefaultNow is the time the server's clock starts at, 9am on a Monday in America/Denver
+var DefaultNow = time.Date(2021, time.January, 4, 16, 0, 0, 0, time.UTC)
+
+// NewServer starts a fake Linear API. Callers should Close it when done.
+func NewServer() *Server {
+	s := &Server{now: DefaultNow}
+	s.Viewer = &User{ID: s.newID("user"), Name: "Linear Autolabeler", DisplayName: "autolabeler", Email: "autolabeler@example.com"}
+	s.users = append(s.users, s.Viewer)
+
+	schema, err := newSchema(s)
+	if err != nil {
+		panic(fmt.Sprintf("lineartest: invalid schema: %v", err))
+	}
+	s.schema = schema
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+// Client returns a LinearClient pointed at the server
+func (s *Server) Client(opts ...linear.Option) *linear.LinearClient {
+	opts = append([]linear.Option{linear.WithBaseURL(s.URL), linear.WithHTTPClient(s.Server.Client())}, opts...)
+	return linear.NewLinearClient("test-token", opts...)
+}
+
+// Now returns the server's current time, which is used for everything created on the server. Pass it as the clock
+// of the code under test so that SLAs are measured against the same time.
+func (s *Server) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now
+}
+
+// Advance moves the server's clock forward
+func (s *Server) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = s.now.Add(d)
+}
+
+// Mutations returns the mutations received so far, in order
+func (s *Server) Mutations() []Mutation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mutation(nil), s.mutations...)
+}
+
+// FailNext makes the next request fail with the status code and headers, without being processed. Each call queues
+// another failure. A 429 also gets Linear's RATELIMITED error code in its body.
+func (s *Server) FailNext(statusCode int, header http.Header) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure{statusCode: statusCode, header: header})
+}
+
+// Requests returns how many requests the server has received, including failed ones
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// ResetMutations forgets the mutations received so far
+func (s *Server) ResetMutations() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mutations = nil
+}
+
+func (s *Server) AddTeam(key string, name string) *Team {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := &Team{ID: s.newID("team"), Key: key, Name: name}
+	s.teams = append(s.teams, t)
+	return t
+}
+
+func (s *Server) AddUser(name string, email string) *User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := &User{ID: s.newID("user"), Name: name, DisplayName: name, Email: email}
+	s.users = append(s.users, u)
+	return u
+}
+
+func (s *Server) AddLabel(team *Team, name string) *Label {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := &Label{ID: s.newID("label"), Name: name, TeamID: team.ID}
+	s.labels = append(s.labels, l)
+	return l
+}
+
+// AddState adds a workflow state of the given type, such as linear.StateTypeStarted, to the team
+func (s *Server) AddState(team *Team, name, stateType string) *State {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := &State{ID: s.newID("state"), Name: name, Type: stateType, TeamID: team.ID}
+	s.states = append(s.states, st)
+	return st
+}
+
+// AddIssue creates an issue in the state at the current time
+func (s *Server) AddIssue(team *Team, title string, state *State) *Issue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	team.nextNumber++
+	i := &Issue{
+		ID:        s.newID("issue"),
+		TeamID:    team.ID,
+		Number:    team.nextNumber,
+		Title:     title,
+		CreatedAt: s.now,
+		UpdatedAt: s.now,
+		StateID:   state.ID,
+	}
+	s.issues = append(s.issues, i)
+	return i
+}
+
+// MoveIssue moves the issue to the state at the current time, recording it in the issue's history
+func (s *Server) MoveIssue(issue *Issue, state *State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.moveIssue(issue, state.ID)
+}
+
+// DeleteIssue removes the issue, so that it is no longer listed and mutating it fails as not found
+func (s *Server) DeleteIssue(issue *Issue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remaining := make([]*Issue, 0, len(s.issues))
+	for _, i := range s.issues {
+		if i != issue {
+			remaining = append(remaining, i)
+		}
+	}
+	s.issues = remaining
+}
+
+// AddComment comments on the issue as the user at the current time
+func (s *Server) AddComment(issue *Issue, user *User, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issue.Comments = append(issue.Comments, &Comment{CreatedAt: s.now, Body: body, UserID: user.ID})
+	issue.UpdatedAt = s.now
+}
+
+// Issue returns a copy of the issue with the given ID or identifier (e.g. INT-1), for checking its current state
+func (s *Server) Issue(id string) (Issue, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.findIssue(id)
+	if i == nil {
+		return Issue{}, false
+	}
+	return *i, true
+}
+
+// LabelNames returns the names of the labels on the issue, sorted
+func (s *Server) LabelNames(issue *Issue) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(issue.LabelIDs))
+	for _, id := range issue.LabelIDs {
+		if l := s.findLabel(id); l != nil {
+			names = append(names, l.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	var fail *failure
+	if len(s.failures) > 0 {
+		fail = &s.failures[0]
+		s.failures = s.failures[1:]
+	}
+	s.mu.Unlock()
+	if fail != nil {
+		writeFailure(w, *fail)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	result := graphql.Do(graphql.Params{
+		Schema:         s.schema,
+		RequestString:  body.Query,
+		VariableValues: body.Variables,
+		Context:        r.Context(),
+	})
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeFailure(w http.ResponseWriter, f failure) {
+	for k, v := range f.header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.statusCode)
+
+	code := "INTERNAL_SERVER_ERROR"
+	if f.statusCode == http.StatusTooManyRequests {
+		code = "RATELIMITED"
+	}
+	fmt.Fprintf(w, `{"errors":[{"message":%q,"extensions":{"code":%q}}]}`, http.StatusText(f.statusCode), code)
+}
+
+// the following must be called with the lock held
+
+func (s *Server) newID(kind string) string {
+	s.nextID++
+	return fmt.Sprintf("%s-%d", kind, s.nextID)
+}
+
+func (s *Server) moveIssue(issue *Issue, stateID string) {
+	issue.History = append(issue.History, &HistoryEntry{
+		CreatedAt:   s.now,
+		FromStateID: issue.StateID,
+		ToStateID:   stateID,
+	})
+	issue.StateID = stateID
+	issue.UpdatedAt = s.now
+}
+
+func (s *Server) findTeam(id string) *Team {
+	for _, t := range s.teams {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+func (s *Server) findUser(id string) *User {
+	for _, u := range s.users {
+		if u.ID == id {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Server) findLabel(id string) *Label {
+	for _, l := range s.labels {
+		if l.ID == id {
+			return l
+		}
+	}
+	return nil
+}
+
+func (s *Server) findState(id string) *State {
+	for _, st := range s.states {
+		if st.ID == id {
+			return st
+		}
+	}
+	return nil
+}
+
+// findIssue finds an issue by its ID or its identifier, e.g. INT-1
+func (s *Server) findIssue(id string) *Issue {
+	for _, i := range s.issues {
+		if i.ID == id || s.identifier(i) == id {
+			return i
+		}
+	}
+	return nil
+}
+
+func (s *Server) identifier(i *Issue) string {
+	return fmt.Sprintf("%s-%d", s.findTeam(i.TeamID).Key, i.Number)
+}
@@ -0,0 +1,93 @@
+package linear
+
+import (
+	"context"
+
+	"github.com/machinebox/graphql"
+)
+
+// nestedPageSize is how many nodes of a nested connection, such as an issue's comments or a team's labels, are loaded
+// by each request for the connection
+const nestedPageSize = 100
+
+// PageFetcher loads the page of a connection after the cursor, keeps its nodes, and returns its page info. The cursor
+// is empty for the first page.
+type PageFetcher func(ctx context.Context, after string) (PageInfo, error)
+
+// Paginate calls fetch for each page of a connection, starting with the page after the cursor, until the connection
+// has no more pages. Any connection can be paged through this way, including connections nested within a node which
+// was loaded by an earlier query; pass the end cursor of the nodes already loaded.
+func Paginate(ctx context.Context, after string, fetch PageFetcher) error {
+	for {
+		pageInfo, err := fetch(ctx, after)
+		if err != nil {
+			return err
+		}
+		if !pageInfo.HasNextPage || pageInfo.EndCursor == "" {
+			return nil
+		}
+		after = pageInfo.EndCursor
+	}
+}
+
+// completeIssue loads the rest of the issue's history, comments, labels and subscribers, when there were more than fit
+// in the page of issues
+func (lc *LinearClient) completeIssue(ctx context.Context, issue *IssueNode) error {
+	connections := []struct {
+		query    string
+		pageInfo *PageInfo
+		// add keeps the nodes of the page, and returns its page info
+		add func(page *IssueNode) PageInfo
+	}{
+		{issueHistoryQuery, &issue.IssueHistory.PageInfo, func(page *IssueNode) PageInfo {
+			issue.IssueHistory.Nodes = append(issue.IssueHistory.Nodes, page.IssueHistory.Nodes...)
+			return page.IssueHistory.PageInfo
+		}},
+		{issueCommentsQuery, &issue.IssueComments.PageInfo, func(page *IssueNode) PageInfo {
+			issue.IssueComments.Nodes = append(issue.IssueComments.Nodes, page.IssueComments.Nodes...)
+			return page.IssueComments.PageInfo
+		}},
+		{issueLabelsQuery, &issue.IssueLabels.PageInfo, func(page *IssueNode) PageInfo {
+			issue.IssueLabels.Nodes = append(issue.IssueLabels.Nodes, page.IssueLabels.Nodes...)
+			return page.IssueLabels.PageInfo
+		}},
+		{issueSubscribersQuery, &issue.Subscribers.PageInfo, func(page *IssueNode) PageInfo {
+			issue.Subscribers.Nodes = append(issue.Subscribers.Nodes, page.Subscribers.Nodes...)
+			return page.Subscribers.PageInfo
+		}},
+	}
+
+	for _, c := range connections {
+		if !c.pageInfo.HasNextPage {
+			continue
+		}
+		err := Paginate(ctx, c.pageInfo.EndCursor, func(ctx context.Context, after string) (PageInfo, error) {
+			page, err := lc.getIssueConnection(ctx, c.query, issue.ID, after)
+			if err != nil {
+				return PageInfo{}, err
+			}
+			*c.pageInfo = c.add(page)
+			return *c.pageInfo, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getIssueConnection runs a query for a page of one of the issue's connections
+func (lc *LinearClient) getIssueConnection(ctx context.Context, query, issueID, after string) (*IssueNode, error) {
+	req := graphql.NewRequest(query)
+	req.Var("id", issueID)
+	req.Var("first", nestedPageSize)
+	req.Var("after", after)
+
+	var response IssueResponse
+	if err := lc.exectueQuery(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Issue, nil
+}
@@ -1,29 +1,15 @@
 package linear
 
 const (
-	teamsQuery = `
-	{
-		teams {
-		  nodes {
-			id
-			name
-		  }
-		}
-	  }
-	`
-
-	issuesQuery = `{
-		team(id: "%s") {
-		  id
-		  name
-	  
-		  issues(%s) {
-			edges {
-				node {
+	// issueFields are the fields loaded for each issue
+	issueFields = `
 					id
 					number
 					createdAt
+					updatedAt
 					title
+					priority
+					dueDate
 					assignee {
 						id
 						name
@@ -31,6 +17,7 @@ const (
 					state {
 						id
 						name
+						type
 					}
 					team {
 						key
@@ -40,12 +27,77 @@ const (
 							createdAt
 							fromState {
 								name
+								type
 							}
 							toState {
 								name
+								type
+							}
+						}
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
+					}
+					comments {
+						nodes {
+							createdAt
+							body
+							user {
+								id
+								name
 							}
 						}
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
 					}
+					labels {
+						nodes {
+							id
+							name
+						}
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
+					}
+					subscribers {
+						nodes {
+							id
+							name
+						}
+						pageInfo {
+							hasNextPage
+							endCursor
+						}
+					}`
+
+	teamsQuery = `query($first: Int, $after: String) {
+		teams(first: $first, after: $after) {
+		  nodes {
+			id
+			name
+			key
+		  }
+		  pageInfo {
+			hasNextPage
+			endCursor
+		  }
+		}
+	  }
+	`
+
+	issuesQuery = `query($teamId: String!, $filter: IssueFilter, $first: Int, $after: String) {
+		team(id: $teamId) {
+		  id
+		  name
+	  
+		  issues(filter: $filter, first: $first, after: $after) {
+			edges {
+				node {
+` + issueFields + `
 				}
 				cursor
 			}
@@ -57,12 +109,39 @@ const (
 		}
 	  }`
 
-	issueCommentsQuery = `{
-		issue(id: "%s") {
+	issueQuery = `query($id: String!) {
+		issue(id: $id) {
+` + issueFields + `
+		}
+	}`
+
+	issueHistoryQuery = `query($id: String!, $first: Int, $after: String) {
+		issue(id: $id) {
+			id
+			history(first: $first, after: $after) {
+				nodes {
+					createdAt
+					fromState {
+						name
+						type
+					}
+					toState {
+						name
+						type
+					}
+				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+			}
+		}
+	}`
+
+	issueCommentsQuery = `query($id: String!, $first: Int, $after: String) {
+		issue(id: $id) {
 			id
-			title
-			description
-			comments {
+			comments(first: $first, after: $after) {
 				nodes {
 					createdAt
 					body
@@ -71,68 +150,124 @@ const (
 						name
 					}
 				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
 			}
 		}
 	}`
 
-	issueLabelsQuery = `{
-		issue(id: "%s") {
+	issueLabelsQuery = `query($id: String!, $first: Int, $after: String) {
+		issue(id: $id) {
 			id
-			labels {
+			labels(first: $first, after: $after) {
 				nodes {
 					id
 					name
 				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
 			}
 		}
 	}`
 
-	workflowStatesQuery = `{
-		workflowStates {
-		  nodes{
+	issueSubscribersQuery = `query($id: String!, $first: Int, $after: String) {
+		issue(id: $id) {
 			id
-			name
-		  }
+			subscribers(first: $first, after: $after) {
+				nodes {
+					id
+					name
+				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+			}
 		}
-	  }`
+	}`
 
-	addIssueLabelMutation = `mutation {
-		issueUpdate(
-		  id: "%s",
-		  input: {
-			labelIds: [%s]
-		  }
-		) {
-		  success
-		  issue {
+	usersQuery = `query($first: Int, $after: String) {
+		users(first: $first, after: $after) {
+			nodes {
+				id
+				name
+				displayName
+				email
+			}
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+		}
+	}`
+
+	viewerQuery = `{
+		viewer {
 			id
-			title
-		  }
+			name
+			displayName
+			email
 		}
-	  }`
+	}`
 
-	addIssueCommentMutation = `mutation {
-  commentCreate(
-    input: {
-      issueId: "%s"
-      body: "%s"
-    }
-  ) {
-    success
-  }
-}`
+	workflowStatesQuery = `query($teamId: String!, $first: Int, $after: String) {
+		team(id: $teamId) {
+			id
+			states(first: $first, after: $after) {
+				nodes {
+					id
+					name
+					type
+				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+			}
+		}
+	}`
 
-	labelsQuery = `{
-		team(id: "%s") {
+	labelsQuery = `query($teamId: String!, $first: Int, $after: String) {
+		team(id: $teamId) {
 			id
 			name
 		
-			labels {
+			labels(first: $first, after: $after) {
 				nodes {
 					id
 					name
+					color
+					description
+					parent {
+						id
+						name
+					}
+				}
+				pageInfo {
+					hasNextPage
+					endCursor
 				}
 			}
 		}
 	}`
+
+	labelCreateMutation = `mutation($input: IssueLabelCreateInput!) {
+  issueLabelCreate(input: $input) {
+    success
+    issueLabel {
+      id
+      name
+    }
+  }
+}`
+
+	labelUpdateMutation = `mutation($id: String!, $input: IssueLabelUpdateInput!) {
+  issueLabelUpdate(id: $id, input: $input) {
+    success
+  }
+}`
 )
@@ -0,0 +1,248 @@
+package linear
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. Network errors, rate limiting, and 5xx responses are retried
+// with jittered exponential backoff; other errors are returned immediately. Mutations are only retried when they were
+// rate limited, or the connection to Linear could not be made, as otherwise they may have been run.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first. Values below one mean a single attempt.
+	MaxAttempts int
+	// BaseDelay is the backoff before the first retry, which doubles for each retry after that
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between attempts
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is used unless a client is created WithRetryPolicy
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// backoff returns a random delay of up to BaseDelay * 2^retry, capped at MaxDelay
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.BaseDelay << uint(retry)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)) + 1)
+}
+
+// Headers Linear uses to describe the request rate limit
+const (
+	rateLimitRemainingHeader = "X-RateLimit-Requests-Remaining"
+	rateLimitResetHeader     = "X-RateLimit-Requests-Reset"
+	retryAfterHeader         = "Retry-After"
+)
+
+// rateLimiter pauses requests once Linear reports the rate limit has been used up, until it resets
+type rateLimiter struct {
+	mu      sync.Mutex
+	resetAt time.Time
+}
+
+func (rl *rateLimiter) wait(ctx context.Context) error {
+	rl.mu.Lock()
+	d := time.Until(rl.resetAt)
+	rl.mu.Unlock()
+
+	return sleep(ctx, d)
+}
+
+func (rl *rateLimiter) update(header http.Header) {
+	if header.Get(rateLimitRemainingHeader) != "0" {
+		return
+	}
+	resetAt, ok := parseReset(header)
+	if !ok {
+		return
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if resetAt.After(rl.resetAt) {
+		rl.resetAt = resetAt
+	}
+}
+
+// parseReset reads when the rate limit resets, which Linear sends as milliseconds since the epoch
+func parseReset(header http.Header) (time.Time, bool) {
+	ms, err := strconv.ParseInt(header.Get(rateLimitResetHeader), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), true
+}
+
+// retryAfter reads how long to wait before retrying, from either the Retry-After header (in seconds) or the time the
+// rate limit resets
+func retryAfter(header http.Header) time.Duration {
+	if seconds, err := strconv.Atoi(header.Get(retryAfterHeader)); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if resetAt, ok := parseReset(header); ok {
+		if d := time.Until(resetAt); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// httpResponse is what the capturing transport saw of the response, since the GraphQL client only reports errors
+type httpResponse struct {
+	received   bool
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+type httpResponseKey struct{}
+
+// capturingTransport records the response to requests which carry an httpResponse in their context
+type capturingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *capturingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	captured, ok := req.Context().Value(httpResponseKey{}).(*httpResponse)
+	if !ok {
+		return res, nil
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	captured.received = true
+	captured.statusCode = res.StatusCode
+	captured.header = res.Header
+	captured.body = body
+
+	return res, nil
+}
+
+// withCapture returns a copy of the HTTP client which captures responses
+func withCapture(httpClient *http.Client) *http.Client {
+	c := *httpClient
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	c.Transport = &capturingTransport{next: next}
+	return &c
+}
+
+// graphqlErrors returns the errors in a GraphQL response body
+func graphqlErrors(body []byte) []*GraphQLError {
+	var response struct {
+		Errors []*GraphQLError `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+	return response.Errors
+}
+
+// classify turns the error from running a request into an APIError, using the captured response
+func classify(err error, res *httpResponse) error {
+	if !res.received {
+		return &APIError{Kind: KindNetwork, Err: err}
+	}
+
+	apiErr := &APIError{StatusCode: res.statusCode, Err: err}
+	apiErr.GraphQLErrors = graphqlErrors(res.body)
+	if len(apiErr.GraphQLErrors) > 0 {
+		apiErr.Err = apiErr.GraphQLErrors[0]
+	}
+	for _, e := range apiErr.GraphQLErrors {
+		switch e.Code() {
+		case "RATELIMITED":
+			apiErr.Kind = KindRateLimited
+		case "AUTHENTICATION_ERROR", "FORBIDDEN":
+			apiErr.Kind = KindAuth
+		}
+	}
+
+	switch {
+	case apiErr.Kind != 0:
+	case res.statusCode == http.StatusTooManyRequests:
+		apiErr.Kind = KindRateLimited
+	case res.statusCode == http.StatusUnauthorized || res.statusCode == http.StatusForbidden:
+		apiErr.Kind = KindAuth
+	case res.statusCode >= 500:
+		apiErr.Kind = KindServer
+	default:
+		apiErr.Kind = KindValidation
+	}
+
+	if apiErr.Kind == KindRateLimited {
+		apiErr.RetryAfter = retryAfter(res.header)
+	}
+
+	return apiErr
+}
+
+// isRetryable returns true if the error is an APIError which may succeed when tried again
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Retryable()
+}
+
+// isResendable returns true if the request failed before Linear could run it, so that a mutation can be sent again
+// without being made twice. This is the case when the rate limit rejected the request, or no connection was made.
+func isResendable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.Kind {
+	case KindRateLimited:
+		return true
+	case KindNetwork:
+		var dnsErr *net.DNSError
+		var opErr *net.OpError
+		return errors.As(apiErr.Err, &dnsErr) || (errors.As(apiErr.Err, &opErr) && opErr.Op == "dial")
+	}
+	return false
+}
@@ -18,6 +18,21 @@ type Team struct {
 	Issues Issues `json:"issues"`
 }
 
+type TeamsResponse struct {
+	Teams Teams `json:"teams"`
+}
+
+type Teams struct {
+	Nodes    []TeamNode `json:"nodes"`
+	PageInfo PageInfo   `json:"pageInfo"`
+}
+
+type TeamNode struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
 type TeamName struct {
 	Key string `json:"key"`
 }
@@ -34,7 +49,7 @@ type Edge struct {
 }
 
 type PageInfo struct {
-	HasNextPage bool   `json:"hasNextPage`
+	HasNextPage bool   `json:"hasNextPage"`
 	EndCursor   string `json:"endCursor"`
 }
 
@@ -46,13 +61,17 @@ type IssueNode struct {
 	ID            string        `json:"id"`
 	Number        int           `json:"number"`
 	CreatedAt     time.Time     `json:"createdAt"`
+	UpdatedAt     time.Time     `json:"updatedAt"`
 	Title         string        `json:"title"`
+	Priority      int           `json:"priority"`
+	DueDate       string        `json:"dueDate"`
 	Assignee      Assignee      `json:"assignee"`
 	State         State         `json:"state"`
 	TeamName      TeamName      `json:"team"`
 	IssueHistory  IssueHistory  `json:"history"`
 	IssueComments IssueComments `json:"comments"`
 	IssueLabels   IssueLabels   `json:"labels"`
+	Subscribers   Users         `json:"subscribers"`
 }
 
 type Assignee struct {
@@ -65,21 +84,78 @@ type User struct {
 	Name string `json:"name"`
 }
 
+type ViewerResponse struct {
+	Viewer UserNode `json:"viewer"`
+}
+
+type UsersResponse struct {
+	Users Users `json:"users"`
+}
+
+type Users struct {
+	Nodes    []UserNode `json:"nodes"`
+	PageInfo PageInfo   `json:"pageInfo"`
+}
+
+type UserNode struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Email       string `json:"email"`
+}
+
+type TeamStatesResponse struct {
+	Team TeamStates `json:"team"`
+}
+
+type TeamStates struct {
+	States States `json:"states"`
+}
+
+type States struct {
+	Nodes    []State  `json:"nodes"`
+	PageInfo PageInfo `json:"pageInfo"`
+}
+
+// Workflow state types. Every state in a team's workflow has one of these types, whatever the state is named.
+const (
+	StateTypeTriage    = "triage"
+	StateTypeBacklog   = "backlog"
+	StateTypeUnstarted = "unstarted"
+	StateTypeStarted   = "started"
+	StateTypeCompleted = "completed"
+	StateTypeCanceled  = "canceled"
+)
+
+// StateTypes are all the workflow state types
+var StateTypes = []string{
+	StateTypeTriage,
+	StateTypeBacklog,
+	StateTypeUnstarted,
+	StateTypeStarted,
+	StateTypeCompleted,
+	StateTypeCanceled,
+}
+
 type State struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
+	Type string `json:"type"`
 }
 
 type IssueHistory struct {
-	Nodes []IssueHistoryNode `json:"nodes"`
+	Nodes    []IssueHistoryNode `json:"nodes"`
+	PageInfo PageInfo           `json:"pageInfo"`
 }
 
 type IssueComments struct {
-	Nodes []IssueCommentNode `json:"nodes"`
+	Nodes    []IssueCommentNode `json:"nodes"`
+	PageInfo PageInfo           `json:"pageInfo"`
 }
 
 type IssueLabels struct {
-	Nodes []IssueLabelNode `json:"nodes"`
+	Nodes    []IssueLabelNode `json:"nodes"`
+	PageInfo PageInfo         `json:"pageInfo"`
 }
 
 type IssueHistoryNode struct {
@@ -95,22 +171,95 @@ type IssueCommentNode struct {
 }
 
 type IssueLabelNode struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Color       string          `json:"color"`
+	Description string          `json:"description"`
+	Parent      *IssueLabelNode `json:"parent"`
 }
 
 type WorkflowState struct {
 	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type LabelCreateResponse struct {
+	IssueLabelCreate LabelPayload `json:"issueLabelCreate"`
 }
 
-type IssueUpdateResponse struct {
-	IssueUpdate SuccessResponse `json:"issueUpdate"`
+type LabelUpdateResponse struct {
+	IssueLabelUpdate SuccessResponse `json:"issueLabelUpdate"`
 }
 
-type CommentCreateResponse struct {
-	CommentCreate SuccessResponse `json:"commentCreate"`
+type LabelPayload struct {
+	Success    bool           `json:"success"`
+	IssueLabel IssueLabelNode `json:"issueLabel"`
 }
 
 type SuccessResponse struct {
 	Success bool `json:"success"`
 }
+
+// IssueUpdateInput is the input of the issueUpdate mutation. Only the fields which are set are updated; Priority is a
+// pointer so that it can be set to no priority.
+type IssueUpdateInput struct {
+	AssigneeID string `json:"assigneeId,omitempty"`
+	StateID    string `json:"stateId,omitempty"`
+	Priority   *int   `json:"priority,omitempty"`
+	DueDate    string `json:"dueDate,omitempty"`
+}
+
+type CommentCreateInput struct {
+	IssueID string `json:"issueId"`
+	Body    string `json:"body"`
+}
+
+// LabelCreateInput is the input of the issueLabelCreate mutation. A group label holds other labels as its children.
+type LabelCreateInput struct {
+	TeamID      string `json:"teamId"`
+	Name        string `json:"name"`
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+	ParentID    string `json:"parentId,omitempty"`
+	IsGroup     bool   `json:"isGroup,omitempty"`
+}
+
+// LabelUpdateInput is the input of the issueLabelUpdate mutation. Only the fields which are set are updated.
+type LabelUpdateInput struct {
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+	ParentID    string `json:"parentId,omitempty"`
+}
+
+// IssueFilter is the filter argument of an issues connection, which Linear applies on the server. Only the fields
+// which are set are applied, and all of them must match.
+type IssueFilter struct {
+	State     *StateFilter    `json:"state,omitempty"`
+	Labels    *LabelsFilter   `json:"labels,omitempty"`
+	UpdatedAt *DateComparator `json:"updatedAt,omitempty"`
+	Assignee  *IDFilter       `json:"assignee,omitempty"`
+}
+
+type StateFilter struct {
+	Name *StringComparator `json:"name,omitempty"`
+	Type *StringComparator `json:"type,omitempty"`
+}
+
+// LabelsFilter matches issues which have some label matching the name
+type LabelsFilter struct {
+	Name *StringComparator `json:"name,omitempty"`
+}
+
+type IDFilter struct {
+	ID *StringComparator `json:"id,omitempty"`
+}
+
+type StringComparator struct {
+	Eq  string   `json:"eq,omitempty"`
+	In  []string `json:"in,omitempty"`
+	Nin []string `json:"nin,omitempty"`
+}
+
+type DateComparator struct {
+	Gt *time.Time `json:"gt,omitempty"`
+}
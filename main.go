@@ -1,100 +1,208 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/jmartin127/linear-autolabeler/config"
 	"github.com/jmartin127/linear-autolabeler/linear"
-	"github.com/jmartin127/linear-autolabeler/sla"
 )
 
-// TODO: Put all of the constants into a config object
-var issueStatesToIgnore = [...]string{"Done", "Canceled"}
+const binaryName = "linear-autolabeler"
 
-const (
-	pageSize = 50
-	teamID   = "99dea3d2-59ff-4273-b8a1-379d36bb1678" // TODO load the team ID from the team name
-	timeZone = "America/Denver"
+// Flags shared by every command
+var (
+	configPath     string
+	teamName       string
+	token          string
+	linearURL      string
+	requestTimeout time.Duration
+	runTimeout     time.Duration
 )
 
-func main() {
-	fmt.Println("Starting...")
+// command is one of the subcommands of the binary, e.g. run
+type command struct {
+	name string
+	// args describes the positional arguments, of which there must be nargs
+	args    string
+	nargs   int
+	summary string
+	// flags adds the command's own flags to those shared by every command
+	flags func(fs *flag.FlagSet)
+	run   func(ctx context.Context, args []string) error
+}
 
-	// initialize the linear client
+// commands are listed in the usage in this order
+var commands = []*command{
+	runCommand,
+	planCommand,
+	metricsCommand,
+	explainCommand,
+	validateConfigCommand,
+	initCommand,
+}
+
+func addSharedFlags(fs *flag.FlagSet) {
+	fs.StringVar(&configPath, "config", "config.yaml", "Path to the YAML config")
+	fs.StringVar(&teamName, "team", "", "Name or key of the team, selecting it from the teams in the config or overriding its only team")
+	fs.StringVar(&token, "token", "", "Linear developer token, defaults to $LINEAR_TOKEN")
+	fs.StringVar(&linearURL, "linear-url", "https://api.linear.app/graphql", "URL of the Linear GraphQL API")
+	fs.DurationVar(&requestTimeout, "request-timeout", 30*time.Second, "Timeout for each request to Linear")
+	fs.DurationVar(&runTimeout, "run-timeout", 30*time.Minute, "Timeout for the whole command, zero for none")
+}
+
+func main() {
 	if len(os.Args) < 2 {
-		log.Fatal("No auth token was provided.\nUsage: go run main.go <auth-token>")
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		if len(os.Args) > 2 && findCommand(os.Args[2]) != nil {
+			fs := newFlagSet(findCommand(os.Args[2]))
+			fs.SetOutput(os.Stdout)
+			fs.Usage()
+			return
+		}
+		usage(os.Stdout)
+		return
 	}
-	authToken := os.Args[1]
-	lc := &linear.LinearClient{
-		Token: authToken,
+
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+		usage(os.Stderr)
+		os.Exit(2)
 	}
 
-	// initialize the SLA client
-	slaClient, err := sla.NewSLA(lc, timeZone)
-	if err != nil {
-		log.Fatal(err)
+	fs := newFlagSet(cmd)
+	fs.Parse(os.Args[2:])
+	if fs.NArg() != cmd.nargs {
+		fs.Usage()
+		os.Exit(2)
 	}
+	if token == "" {
+		token = os.Getenv("LINEAR_TOKEN")
+	}
+
+	// stop the command on SIGINT/SIGTERM, or once it takes too long
+	ctx, cancel := runContext(runTimeout)
+	defer cancel()
 
-	// find the "ExceedsSLA" label
-	fmt.Println("Finding Exceeds SLA label...")
-	exceedsSLALabelID, err := lc.FindLabelIDWithName(teamID, "ExceedsSLA")
-	if err != nil {
+	if err := cmd.run(ctx, fs.Args()); err != nil {
 		log.Fatal(err)
 	}
+}
 
-	var totalIssues int
-	pagination := fmt.Sprintf("first:%d", pageSize)
-	for true {
-		fmt.Printf("Loading issues for team %s and page %s\n", teamID, pagination)
-		response, err := lc.GetIssuesForTeam(teamID, pagination)
-		if err != nil {
-			log.Fatal(err)
+func findCommand(name string) *command {
+	for _, c := range commands {
+		if c.name == name {
+			return c
 		}
+	}
+	return nil
+}
 
-		for _, v := range response.Team.Issues.Edges {
-			if shouldIgnoreState(v.IssueNode.State.Name) {
-				continue
-			}
+// newFlagSet creates the command's flags, with a usage which lists them
+func newFlagSet(cmd *command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	addSharedFlags(fs)
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintf(w, "Usage: %s %s [flags] %s\n\n%s\n\nFlags:\n", binaryName, cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
 
-			exceeds, durationExceeding, sla := slaClient.ExceedsSLA(&v.IssueNode)
-			ticketNumber := linear.TicketNumber(&v.IssueNode)
-			if exceeds {
-				addedLabel, err := lc.AddLabelToTicket(ticketNumber, exceedsSLALabelID)
-				if err != nil {
-					log.Fatal(err)
-				}
-				if addedLabel {
-					comment := fmt.Sprintf("Uh oh!  This ticket is in the %s state, and exceeds the SLA by %s!  FYI, the SLA is %+v (in business hours).", v.IssueNode.State.Name, durationExceeding, sla)
-					log.Printf("Ticket: %s, Adding Comment: %s\n", ticketNumber, comment)
-					if err := lc.AddCommentToTicket(v.IssueNode.ID, comment); err != nil {
-						log.Fatal(err)
-					}
-				}
-			} else {
-				if err := lc.RemoveLabelFromTicket(ticketNumber, exceedsSLALabelID); err != nil {
-					log.Fatal(err)
-				}
-			}
-			totalIssues++
+// usage lists the commands
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", binaryName)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-22s %s\n", strings.TrimSpace(c.name+" "+c.args), c.summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s help <command>' for the flags of a command.\n", binaryName)
+}
+
+// loadConfig loads the config, applying the --team flag
+func loadConfig() (*config.Config, error) {
+	return config.Load(configPath, teamName)
+}
+
+func newClient(token string) *linear.LinearClient {
+	return linear.NewLinearClient(token, linear.WithBaseURL(linearURL), linear.WithTimeout(requestTimeout))
+}
+
+// workspaceTeam is a team from the config, with a client for its workspace. Err is set if the team cannot be run.
+type workspaceTeam struct {
+	workspace string
+	team      config.TeamConfig
+	lc        *linear.LinearClient
+	err       error
+}
+
+// configTeams returns every team of every workspace in the config. Teams in the default workspace use the --token
+// flag, while teams in other workspaces use the token in their workspace's tokenEnv.
+func configTeams(cfg *config.Config) []workspaceTeam {
+	teams := make([]workspaceTeam, 0)
+	for _, workspace := range cfg.AllWorkspaces() {
+		workspaceToken := token
+		missingToken := fmt.Errorf("no token was provided, pass it with --token or set LINEAR_TOKEN")
+		if workspace.TokenEnv != "" {
+			workspaceToken = os.Getenv(workspace.TokenEnv)
+			missingToken = fmt.Errorf("no token was found in %s", workspace.TokenEnv)
 		}
 
-		// pagination
-		pagination = fmt.Sprintf(`first:%d after:"%s"`, pageSize, response.Team.Issues.PageInfo.EndCursor)
-		if response.Team.Issues.PageInfo.HasNextPage == false {
-			break
+		lc := newClient(workspaceToken)
+		for _, team := range workspace.Teams {
+			t := workspaceTeam{workspace: workspace.Name, team: team, lc: lc}
+			switch {
+			case team.Team == "":
+				t.err = fmt.Errorf("no team was provided, set it in the config or with --team")
+			case workspaceToken == "":
+				t.err = missingToken
+			}
+			teams = append(teams, t)
 		}
 	}
 
-	fmt.Printf("Total issues: %d\n", totalIssues)
+	return teams
 }
 
-func shouldIgnoreState(state string) bool {
-	for _, ignoredState := range issueStatesToIgnore {
-		if state == ignoredState {
-			return true
-		}
+// runContext returns a context which is cancelled on SIGINT or SIGTERM, or once the timeout passes
+func runContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
 	}
 
-	return false
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case sig := <-signals:
+			log.Printf("Received %s, stopping...\n", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
 }
@@ -1,9 +1,9 @@
-package main
+// Package metrics reports how long the tickets of a team spent in each state, and how many were created each week.
+package metrics
 
 import (
-	"flag"
+	"context"
 	"fmt"
-	"log"
 	"sort"
 	"time"
 
@@ -11,18 +11,6 @@ import (
 	"github.com/jmartin127/linear-autolabeler/sla"
 )
 
-var token string
-
-func init() {
-	flag.StringVar(&token, "t", "", "Linear Developer Token")
-	flag.Parse()
-}
-
-const (
-	pageSize = 50
-	teamID   = "99dea3d2-59ff-4273-b8a1-379d36bb1678" // TODO load the team ID from the team name
-)
-
 type week struct {
 	start time.Time
 	end   time.Time
@@ -57,66 +45,46 @@ func (ms *metricsSummary) printResults() {
 	}
 }
 
-func main() {
+// Run reads every issue of the team, a page of pageSize at a time, and prints the time the "OB Techs" tickets which are
+// done spent in each state, and the number of tickets created each week
+func Run(ctx context.Context, lc *linear.LinearClient, teamID string, pageSize int) error {
 	fmt.Println("Starting metrics gathering...")
 
-	if token == "" {
-		log.Fatal("No auth token was provided.\nUsage: go run main.go -t <auth-token>")
-	}
-	lc := &linear.LinearClient{
-		Token: token,
-	}
-
-	obTechLabelID, err := lc.FindLabelIDWithName(teamID, "OB Techs")
+	obTechLabelID, err := lc.FindLabelIDWithName(ctx, teamID, "OB Techs")
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	// Create bins for arregating ticket creation dates
 	layout := "2006-01-02T15:04:05.000Z"
 	start, err := time.Parse(layout, "2020-01-06T00:00:00.000Z")
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	end, err := time.Parse(layout, "2020-12-25T00:00:00.000Z")
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	numTicketsByWeek := createDateSlice(start, end)
 	fmt.Printf("Num weeks %d\n", len(numTicketsByWeek))
 
 	var totalIssues int
-	pagination := fmt.Sprintf("first:%d", pageSize)
 	summary := newMetricsSummary()
-	for true {
-		fmt.Printf("Loading issues for team %s and page %s\n", teamID, pagination)
-		response, err := lc.GetIssuesForTeam(teamID, pagination)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		for _, v := range response.Team.Issues.Edges {
-			// aggregate ticket creation dates
-			addCreationDateToAggregate(numTicketsByWeek, getIssueCreationDate(&v.IssueNode))
-
-			if v.IssueNode.State.Name == "Done" {
-				hasObTechLabel, err := lc.TicketHasLabel(linear.TicketNumber(&v.IssueNode), obTechLabelID)
-				if err != nil {
-					log.Fatal(err)
-				}
-				if hasObTechLabel {
-					issueMetrics := gatherMetricsFromIssue(&v.IssueNode)
-					summary = addResultToSummary(summary, issueMetrics)
-					totalIssues++
-				}
+	err = lc.Issues(ctx, linear.IssueQuery{TeamID: teamID, PageSize: pageSize}, func(issue *linear.IssueNode) error {
+		// aggregate ticket creation dates
+		addCreationDateToAggregate(numTicketsByWeek, getIssueCreationDate(issue))
+
+		if issue.State.Name == "Done" {
+			if linear.TicketHasLabel(issue, obTechLabelID) {
+				issueMetrics := gatherMetricsFromIssue(issue)
+				summary = addResultToSummary(summary, issueMetrics)
+				totalIssues++
 			}
 		}
-
-		// pagination
-		pagination = fmt.Sprintf(`first:%d after:"%s"`, pageSize, response.Team.Issues.PageInfo.EndCursor)
-		if response.Team.Issues.PageInfo.HasNextPage == false {
-			break
-		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	fmt.Printf("Read %d issues\n", totalIssues)
@@ -126,6 +94,8 @@ func main() {
 	for _, v := range numTicketsByWeek {
 		fmt.Printf("%+v\t%+v\t%d\n", v.start, v.end, v.count)
 	}
+
+	return nil
 }
 
 func createDateSlice(start, end time.Time) []*week {
@@ -0,0 +1,98 @@
+package rules
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jmartin127/linear-autolabeler/linear"
+)
+
+// Filter decides whether an issue matches part of a rule. String describes what the filter matches.
+type Filter interface {
+	Match(ctx context.Context, issue *linear.IssueNode) (Result, error)
+	String() string
+}
+
+// Result is the outcome of matching a filter against an issue. For time based filters, Exceeding is how much the
+// SLA was exceeded by, and SLA is the configured limit.
+type Result struct {
+	Matched   bool
+	Exceeding time.Duration
+	SLA       time.Duration
+}
+
+// All matches when every one of its filters match. Evaluation stops at the first filter which does not match, so
+// cheap filters should be listed before ones which call the Linear API.
+type All []Filter
+
+func (a All) Match(ctx context.Context, issue *linear.IssueNode) (Result, error) {
+	var result Result
+	for _, f := range a {
+		r, err := f.Match(ctx, issue)
+		if err != nil {
+			return Result{}, err
+		}
+		if !r.Matched {
+			return Result{}, nil
+		}
+		// report the timings of the last time based filter
+		if r.SLA > 0 {
+			result = r
+		}
+	}
+
+	result.Matched = true
+	return result, nil
+}
+
+func (a All) String() string {
+	return "all of (" + joinFilters(a) + ")"
+}
+
+// Any matches when at least one of its filters match, reporting the result of the first match
+type Any []Filter
+
+func (a Any) Match(ctx context.Context, issue *linear.IssueNode) (Result, error) {
+	for _, f := range a {
+		r, err := f.Match(ctx, issue)
+		if err != nil {
+			return Result{}, err
+		}
+		if r.Matched {
+			return r, nil
+		}
+	}
+
+	return Result{}, nil
+}
+
+func (a Any) String() string {
+	return "any of (" + joinFilters(a) + ")"
+}
+
+// Not matches when its filter does not
+type Not struct {
+	Filter Filter
+}
+
+func (n Not) Match(ctx context.Context, issue *linear.IssueNode) (Result, error) {
+	r, err := n.Filter.Match(ctx, issue)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{Matched: !r.Matched}, nil
+}
+
+func (n Not) String() string {
+	return "not (" + n.Filter.String() + ")"
+}
+
+func joinFilters(filters []Filter) string {
+	descriptions := make([]string, 0, len(filters))
+	for _, f := range filters {
+		descriptions = append(descriptions, f.String())
+	}
+	return strings.Join(descriptions, ", ")
+}
@@ -0,0 +1,176 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jmartin127/linear-autolabeler/linear"
+	"github.com/jmartin127/linear-autolabeler/sla"
+)
+
+// SLAInState matches issues in the given state, or a state of the given type, for longer than the SLA (in business
+// hours). The time is measured from the last time the issue entered EnteredState, or a state of EnteredStateType,
+// which defaults to the current state.
+type SLAInState struct {
+	State            string
+	StateType        string
+	EnteredState     string
+	EnteredStateType string
+	LongerThan       time.Duration
+	Location         *time.Location
+	Now              func() time.Time
+}
+
+func (f *SLAInState) Match(ctx context.Context, issue *linear.IssueNode) (Result, error) {
+	if !stateMatches(issue.State, f.State, f.StateType) {
+		return Result{}, nil
+	}
+
+	var timeEnteredState time.Time
+	switch {
+	case f.EnteredState != "":
+		timeEnteredState = linear.GetLastTimeIssueEnteredState(issue, f.EnteredState)
+	case f.EnteredStateType != "":
+		timeEnteredState = linear.GetLastTimeIssueEnteredStateType(issue, f.EnteredStateType)
+	default:
+		timeEnteredState = linear.GetLastTimeIssueEnteredState(issue, issue.State.Name)
+	}
+
+	return exceedsSLA(timeEnteredState, f.Now(), f.Location, f.LongerThan), nil
+}
+
+func (f *SLAInState) String() string {
+	s := fmt.Sprintf("%s for longer than %s", describeState(f.State, f.StateType), f.LongerThan)
+	if f.EnteredState != "" || f.EnteredStateType != "" {
+		s += fmt.Sprintf(" since entering %s", describeState(f.EnteredState, f.EnteredStateType))
+	}
+	return s
+}
+
+// LastComment matches issues which have not been commented on for longer than the SLA (in business hours). Comments
+// made by the auto-labeler itself, and the other bots in IgnoreUserIDs, are ignored. Issues which have never been
+// commented on are measured from when they were created.
+type LastComment struct {
+	LongerThan    time.Duration
+	Location      *time.Location
+	Now           func() time.Time
+	IgnoreUserIDs []string
+}
+
+func (f *LastComment) Match(ctx context.Context, issue *linear.IssueNode) (Result, error) {
+	lastCommentTime := linear.GetLastTimeIssueWasCommentedOn(issue, f.IgnoreUserIDs)
+	if lastCommentTime.IsZero() {
+		lastCommentTime = issue.CreatedAt
+	}
+
+	return exceedsSLA(lastCommentTime, f.Now(), f.Location, f.LongerThan), nil
+}
+
+func (f *LastComment) String() string {
+	return fmt.Sprintf("not commented on for longer than %s", f.LongerThan)
+}
+
+// HasLabel matches issues which currently have the label with the given name
+type HasLabel struct {
+	Label string
+}
+
+func (f *HasLabel) Match(ctx context.Context, issue *linear.IssueNode) (Result, error) {
+	for _, l := range issue.IssueLabels.Nodes {
+		if l.Name == f.Label {
+			return Result{Matched: true}, nil
+		}
+	}
+
+	return Result{}, nil
+}
+
+func (f *HasLabel) String() string {
+	return fmt.Sprintf("has label %q", f.Label)
+}
+
+// AssigneeIs matches issues assigned to the user with the given name or ID. An empty assignee matches unassigned
+// issues.
+type AssigneeIs struct {
+	Assignee string
+}
+
+func (f *AssigneeIs) Match(ctx context.Context, issue *linear.IssueNode) (Result, error) {
+	matched := issue.Assignee.Name == f.Assignee || issue.Assignee.ID == f.Assignee
+	return Result{Matched: matched}, nil
+}
+
+func (f *AssigneeIs) String() string {
+	if f.Assignee == "" {
+		return "unassigned"
+	}
+	return fmt.Sprintf("assigned to %q", f.Assignee)
+}
+
+// StateIs matches issues in the given state, or a state of the given type
+type StateIs struct {
+	State     string
+	StateType string
+}
+
+func (f *StateIs) Match(ctx context.Context, issue *linear.IssueNode) (Result, error) {
+	return Result{Matched: stateMatches(issue.State, f.State, f.StateType)}, nil
+}
+
+func (f *StateIs) String() string {
+	return describeState(f.State, f.StateType)
+}
+
+// TitleMatches matches issues with a title matching the regular expression
+type TitleMatches struct {
+	Pattern *regexp.Regexp
+}
+
+func (f *TitleMatches) Match(ctx context.Context, issue *linear.IssueNode) (Result, error) {
+	return Result{Matched: f.Pattern.MatchString(issue.Title)}, nil
+}
+
+func (f *TitleMatches) String() string {
+	return fmt.Sprintf("title matches %q", f.Pattern)
+}
+
+func exceedsSLA(refTime time.Time, now time.Time, loc *time.Location, limit time.Duration) Result {
+	exceeds, durationExceeding, slaDuration := sla.ExceedsSLAInBusinessHours(refTime, now, loc, limit)
+	if !exceeds {
+		return Result{}
+	}
+
+	return Result{
+		Matched:   true,
+		Exceeding: durationExceeding,
+		SLA:       slaDuration,
+	}
+}
+
+// stateMatches returns true if the state has the given name and type, ignoring either when it is empty
+func stateMatches(state linear.State, name, stateType string) bool {
+	if name != "" && state.Name != name {
+		return false
+	}
+	if stateType != "" && !strings.EqualFold(state.Type, stateType) {
+		return false
+	}
+
+	return true
+}
+
+// describeState describes the states matched by stateMatches
+func describeState(name, stateType string) string {
+	switch {
+	case name != "" && stateType != "":
+		return fmt.Sprintf("in state %q of type %s", name, stateType)
+	case name != "":
+		return fmt.Sprintf("in state %q", name)
+	case stateType != "":
+		return fmt.Sprintf("in a %s state", stateType)
+	}
+	return "in any state"
+}
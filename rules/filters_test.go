@@ -0,0 +1,134 @@
+package rules
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jmartin127/linear-autolabeler/config"
+	"github.com/jmartin127/linear-autolabeler/linear"
+)
+
+// monday is the start of a working week, so that each hour of the day after it is a business hour
+var monday = time.Date(2022, time.June, 6, 9, 0, 0, 0, time.UTC)
+
+func at(hours int) func() time.Time {
+	return func() time.Time { return monday.Add(time.Duration(hours) * time.Hour) }
+}
+
+func testIssue() *linear.IssueNode {
+	return &linear.IssueNode{
+		CreatedAt: monday,
+		Title:     "Customer cannot log in",
+		Assignee:  linear.Assignee{ID: "user-1", Name: "Ada"},
+		State:     linear.State{Name: "In Review", Type: linear.StateTypeStarted},
+		IssueHistory: linear.IssueHistory{Nodes: []linear.IssueHistoryNode{
+			{CreatedAt: monday.Add(1 * time.Hour), ToState: linear.WorkflowState{Name: "In Progress", Type: linear.StateTypeStarted}},
+			{CreatedAt: monday.Add(3 * time.Hour), ToState: linear.WorkflowState{Name: "In Review", Type: linear.StateTypeStarted}},
+		}},
+		IssueComments: linear.IssueComments{Nodes: []linear.IssueCommentNode{
+			{CreatedAt: monday.Add(2 * time.Hour), User: linear.User{ID: "user-1"}},
+			{CreatedAt: monday.Add(6 * time.Hour), User: linear.User{ID: "bot"}},
+		}},
+		IssueLabels: linear.IssueLabels{Nodes: []linear.IssueLabelNode{{ID: "label-1", Name: "Bug"}}},
+	}
+}
+
+func TestFilters(t *testing.T) {
+	uncommented := testIssue()
+	uncommented.IssueComments.Nodes = nil
+
+	tests := []struct {
+		name          string
+		filter        Filter
+		issue         *linear.IssueNode
+		want          bool
+		wantExceeding time.Duration
+	}{
+		{"SLA in the current state", &SLAInState{State: "In Review", LongerThan: 4 * time.Hour, Location: time.UTC, Now: at(8)}, nil, true, time.Hour},
+		{"SLA not yet exceeded", &SLAInState{State: "In Review", LongerThan: 4 * time.Hour, Location: time.UTC, Now: at(6)}, nil, false, 0},
+		{"SLA in another state", &SLAInState{State: "Todo", LongerThan: time.Hour, Location: time.UTC, Now: at(8)}, nil, false, 0},
+		{"SLA by state type", &SLAInState{StateType: "Started", EnteredStateType: linear.StateTypeStarted, LongerThan: 4 * time.Hour, Location: time.UTC, Now: at(8)}, nil, true, time.Hour},
+		{"SLA since entering a state", &SLAInState{State: "In Review", EnteredState: "In Progress", LongerThan: 4 * time.Hour, Location: time.UTC, Now: at(8)}, nil, true, 3 * time.Hour},
+		{"SLA since creation", &SLAInState{State: "In Review", EnteredState: "Todo", LongerThan: 4 * time.Hour, Location: time.UTC, Now: at(8)}, nil, true, 4 * time.Hour},
+		{"last comment", &LastComment{LongerThan: 4 * time.Hour, Location: time.UTC, Now: at(8)}, nil, false, 0},
+		{"last comment ignoring bots", &LastComment{LongerThan: 4 * time.Hour, Location: time.UTC, Now: at(8), IgnoreUserIDs: []string{"bot"}}, nil, true, 2 * time.Hour},
+		{"never commented on", &LastComment{LongerThan: 4 * time.Hour, Location: time.UTC, Now: at(8)}, uncommented, true, 4 * time.Hour},
+		{"has label", &HasLabel{Label: "Bug"}, nil, true, 0},
+		{"does not have label", &HasLabel{Label: "Feature"}, nil, false, 0},
+		{"assignee by name", &AssigneeIs{Assignee: "Ada"}, nil, true, 0},
+		{"assignee by ID", &AssigneeIs{Assignee: "user-1"}, nil, true, 0},
+		{"unassigned", &AssigneeIs{}, nil, false, 0},
+		{"state by name", &StateIs{State: "In Review"}, nil, true, 0},
+		{"state by type", &StateIs{StateType: "STARTED"}, nil, true, 0},
+		{"state by name and type", &StateIs{State: "In Review", StateType: linear.StateTypeUnstarted}, nil, false, 0},
+		{"title matches", &TitleMatches{Pattern: regexp.MustCompile(`(?i)log ?in`)}, nil, true, 0},
+		{"title does not match", &TitleMatches{Pattern: regexp.MustCompile(`^Feature`)}, nil, false, 0},
+		{"all", All{&HasLabel{Label: "Bug"}, &StateIs{State: "In Review"}}, nil, true, 0},
+		{"all with one not matching", All{&HasLabel{Label: "Bug"}, &StateIs{State: "Todo"}}, nil, false, 0},
+		{"all reports the SLA", All{&HasLabel{Label: "Bug"}, &LastComment{LongerThan: 4 * time.Hour, Location: time.UTC, Now: at(8), IgnoreUserIDs: []string{"bot"}}}, nil, true, 2 * time.Hour},
+		{"any", Any{&HasLabel{Label: "Feature"}, &StateIs{State: "In Review"}}, nil, true, 0},
+		{"any with none matching", Any{&HasLabel{Label: "Feature"}, &StateIs{State: "Todo"}}, nil, false, 0},
+		{"not", Not{Filter: &HasLabel{Label: "Feature"}}, nil, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issue := tt.issue
+			if issue == nil {
+				issue = testIssue()
+			}
+			got, err := tt.filter.Match(context.Background(), issue)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Matched != tt.want || got.Exceeding != tt.wantExceeding {
+				t.Errorf("%s: Match() = %t exceeding %s, want %t exceeding %s", tt.filter, got.Matched, got.Exceeding, tt.want, tt.wantExceeding)
+			}
+		})
+	}
+}
+
+func TestBuildFilter(t *testing.T) {
+	env := Env{Location: time.UTC, Now: at(8), BotUserIDs: []string{"bot"}}
+
+	tests := []struct {
+		name    string
+		filter  config.Filter
+		want    string
+		wantErr bool
+	}{
+		{"SLA", config.Filter{Type: "sla", CurrentState: "Todo", LongerThan: time.Hour}, `in state "Todo" for longer than 1h0m0s`, false},
+		{"last comment", config.Filter{Type: "LastComment", LongerThan: time.Hour}, "not commented on for longer than 1h0m0s", false},
+		{"title", config.Filter{Type: "TitleMatches", Pattern: "^Bug"}, `title matches "^Bug"`, false},
+		{"invalid title", config.Filter{Type: "TitleMatches", Pattern: "("}, "", true},
+		{
+			"nested",
+			config.Filter{Type: "any", Filter: []config.Filter{
+				{Type: "not", Filter: []config.Filter{{Type: "HasLabel", Label: "Bug"}}},
+				{Type: "all", Filter: []config.Filter{{Type: "AssigneeIs"}, {Type: "StateIs", StateType: "started"}}},
+			}},
+			`any of (not (has label "Bug"), all of (unassigned, in a started state))`,
+			false,
+		},
+		{"not with two filters", config.Filter{Type: "not", Filter: []config.Filter{{Type: "AssigneeIs"}, {Type: "AssigneeIs"}}}, "", true},
+		{"unknown", config.Filter{Type: "Nope"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildFilter(tt.filter, env)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("buildFilter() = %s, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("buildFilter() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
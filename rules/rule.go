@@ -0,0 +1,120 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jmartin127/linear-autolabeler/actions"
+	"github.com/jmartin127/linear-autolabeler/config"
+)
+
+// Rule is a job from the config, with its filters combined into a single filter tree
+type Rule struct {
+	Name    string
+	Filter  Filter
+	Actions []actions.Action
+}
+
+// Env holds what the filters and actions need in order to be evaluated. Now defaults to time.Now. BotUserIDs are the
+// users, such as the auto-labeler itself, whose comments do not count as activity on an issue.
+type Env struct {
+	Location   *time.Location
+	Now        func() time.Time
+	BotUserIDs []string
+	Actions    *actions.Env
+}
+
+// Build turns each of the configured jobs into a rule. The top level filters of a job must all match.
+func Build(ctx context.Context, jobs []config.Job, env Env) ([]*Rule, error) {
+	if env.Now == nil {
+		env.Now = time.Now
+	}
+
+	rules := make([]*Rule, 0, len(jobs))
+	for _, job := range jobs {
+		filter, err := buildAll(job.Filter, env)
+		if err != nil {
+			return nil, fmt.Errorf("job %q: %w", job.Name, err)
+		}
+		ruleActions, err := actions.Build(ctx, job.ActionList(), env.Actions)
+		if err != nil {
+			return nil, fmt.Errorf("job %q: %w", job.Name, err)
+		}
+		rules = append(rules, &Rule{
+			Name:    job.Name,
+			Filter:  filter,
+			Actions: ruleActions,
+		})
+	}
+
+	return rules, nil
+}
+
+func buildAll(filters []config.Filter, env Env) (All, error) {
+	all := make(All, 0, len(filters))
+	for _, f := range filters {
+		built, err := buildFilter(f, env)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, built)
+	}
+
+	return all, nil
+}
+
+func buildFilter(f config.Filter, env Env) (Filter, error) {
+	switch {
+	case strings.EqualFold(f.Type, config.FilterTypeSLA):
+		return &SLAInState{
+			State:            f.CurrentState,
+			StateType:        f.CurrentStateType,
+			EnteredState:     f.EnteredState,
+			EnteredStateType: f.EnteredStateType,
+			LongerThan:       f.LongerThan,
+			Location:         env.Location,
+			Now:              env.Now,
+		}, nil
+	case strings.EqualFold(f.Type, config.FilterTypeLastComment):
+		return &LastComment{
+			LongerThan:    f.LongerThan,
+			Location:      env.Location,
+			Now:           env.Now,
+			IgnoreUserIDs: env.BotUserIDs,
+		}, nil
+	case strings.EqualFold(f.Type, config.FilterTypeHasLabel):
+		return &HasLabel{Label: f.Label}, nil
+	case strings.EqualFold(f.Type, config.FilterTypeAssigneeIs):
+		return &AssigneeIs{Assignee: f.Assignee}, nil
+	case strings.EqualFold(f.Type, config.FilterTypeStateIs):
+		return &StateIs{State: f.State, StateType: f.StateType}, nil
+	case strings.EqualFold(f.Type, config.FilterTypeTitleMatches):
+		pattern, err := regexp.Compile(f.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		return &TitleMatches{Pattern: pattern}, nil
+	case strings.EqualFold(f.Type, config.FilterTypeAll):
+		return buildAll(f.Filter, env)
+	case strings.EqualFold(f.Type, config.FilterTypeAny):
+		all, err := buildAll(f.Filter, env)
+		if err != nil {
+			return nil, err
+		}
+		return Any(all), nil
+	case strings.EqualFold(f.Type, config.FilterTypeNot):
+		if len(f.Filter) != 1 {
+			return nil, fmt.Errorf("%s filter requires exactly one nested filter", f.Type)
+		}
+		nested, err := buildFilter(f.Filter[0], env)
+		if err != nil {
+			return nil, err
+		}
+		return Not{Filter: nested}, nil
+	}
+
+	return nil, fmt.Errorf("unknown filter type %q", f.Type)
+}
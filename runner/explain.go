@@ -0,0 +1,165 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jmartin127/linear-autolabeler/config"
+	"github.com/jmartin127/linear-autolabeler/linear"
+	"github.com/jmartin127/linear-autolabeler/rules"
+)
+
+// FilterExplanation is whether one of a rule's filters matched the ticket. For time based filters, Exceeding is how
+// much the SLA was exceeded by.
+type FilterExplanation struct {
+	Filter    string
+	Matched   bool
+	SLA       time.Duration
+	Exceeding time.Duration
+}
+
+// RuleExplanation is whether a rule matched the ticket, and which of its filters did
+type RuleExplanation struct {
+	Rule    string
+	Matched bool
+	Filters []FilterExplanation
+}
+
+// Explanation is why each of a team's rules did or did not match a ticket, and what a run would do to the ticket
+type Explanation struct {
+	Ticket string
+	Title  string
+	State  linear.State
+	// Ignored is set if the ticket's state is ignored, so runs leave the ticket alone
+	Ignored bool
+	Rules   []RuleExplanation
+	Plan    *TicketPlan
+}
+
+// ExplainTicket evaluates every rule of the team against the ticket, e.g. INT-123, without changing anything in Linear.
+// Every filter of each rule is evaluated, even once one has not matched, so that all of the reasons a rule did not
+// match are shown.
+func ExplainTicket(ctx context.Context, lc *linear.LinearClient, teamConfig config.TeamConfig, ticketNumber string, opts Options) (*Explanation, error) {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	lc = lc.DryRun()
+
+	prepared, err := prepareTeam(ctx, lc, lc, teamConfig, opts.Now)
+	if err != nil {
+		return nil, err
+	}
+
+	issue, err := lc.GetIssue(ctx, ticketNumber)
+	if err != nil {
+		return nil, fmt.Errorf("loading ticket %s: %w", ticketNumber, err)
+	}
+	if !strings.EqualFold(issue.TeamName.Key, prepared.team.Key) {
+		return nil, fmt.Errorf("ticket %s belongs to team %s, not %s", ticketNumber, issue.TeamName.Key, prepared.team.Key)
+	}
+
+	explanation := &Explanation{
+		Ticket:  linear.TicketNumber(issue),
+		Title:   issue.Title,
+		State:   issue.State,
+		Ignored: teamConfig.ShouldIgnoreState(issue.State),
+	}
+	for _, rule := range prepared.ruleSet {
+		ruleExplanation, err := explainRule(ctx, rule, issue)
+		if err != nil {
+			return nil, err
+		}
+		explanation.Rules = append(explanation.Rules, ruleExplanation)
+	}
+
+	if !explanation.Ignored {
+		explanation.Plan, err = processIssue(ctx, lc, prepared.ruleSet, issue, log.New(ioutil.Discard, "", 0))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return explanation, nil
+}
+
+func explainRule(ctx context.Context, rule *rules.Rule, issue *linear.IssueNode) (RuleExplanation, error) {
+	explanation := RuleExplanation{Rule: rule.Name, Matched: true}
+
+	// the top level filters of a rule must all match, so explain each of them, and whether the rule matched follows
+	// from their results
+	filters := []rules.Filter{rule.Filter}
+	if all, ok := rule.Filter.(rules.All); ok {
+		filters = all
+	}
+	for _, f := range filters {
+		r, err := f.Match(ctx, issue)
+		if err != nil {
+			return explanation, err
+		}
+		explanation.Matched = explanation.Matched && r.Matched
+		explanation.Filters = append(explanation.Filters, FilterExplanation{
+			Filter:    f.String(),
+			Matched:   r.Matched,
+			SLA:       r.SLA,
+			Exceeding: r.Exceeding,
+		})
+	}
+
+	return explanation, nil
+}
+
+// WriteText writes the explanation for people to read
+func (e *Explanation) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", e.Ticket, e.Title)
+	fmt.Fprintf(&b, "State: %s (%s)\n", e.State.Name, e.State.Type)
+	if e.Ignored {
+		fmt.Fprintln(&b, "The state is ignored, so runs leave this ticket alone")
+	}
+
+	for _, r := range e.Rules {
+		verdict := "did not match"
+		if r.Matched {
+			verdict = "matched"
+		}
+		fmt.Fprintf(&b, "\nRule %q %s\n", r.Rule, verdict)
+		for _, f := range r.Filters {
+			mark := "no "
+			if f.Matched {
+				mark = "yes"
+			}
+			fmt.Fprintf(&b, "  [%s] %s", mark, f.Filter)
+			if f.Exceeding > 0 {
+				fmt.Fprintf(&b, ", exceeding the SLA of %s by %s", f.SLA, f.Exceeding)
+			}
+			fmt.Fprintln(&b)
+		}
+	}
+
+	if e.Plan != nil {
+		fmt.Fprintln(&b, "\nA run would:")
+		if !e.Plan.changed() {
+			fmt.Fprintln(&b, "  make no changes")
+		}
+		for _, l := range e.Plan.AddLabels {
+			fmt.Fprintf(&b, "  add label: %s\n", l)
+		}
+		for _, l := range e.Plan.RemoveLabels {
+			fmt.Fprintf(&b, "  remove label: %s\n", l)
+		}
+		for _, c := range e.Plan.Comments {
+			fmt.Fprintf(&b, "  comment: %s\n", c)
+		}
+		for _, c := range e.Plan.Changes {
+			fmt.Fprintf(&b, "  %s\n", c)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
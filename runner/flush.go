@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jmartin127/linear-autolabeler/linear"
+)
+
+// flusher sends the batcher's operations in the background, with up to workers requests in flight at once, so that
+// the issues keep being read and evaluated while the mutations of earlier issues are sent
+type flusher struct {
+	ctx     context.Context
+	batcher *linear.Batcher
+	slots   chan struct{}
+	wg      sync.WaitGroup
+
+	mu  sync.Mutex
+	ops []*linear.Operation
+	err error
+}
+
+func newFlusher(ctx context.Context, batcher *linear.Batcher, workers int) *flusher {
+	if workers < 1 {
+		workers = 1
+	}
+	return &flusher{ctx: ctx, batcher: batcher, slots: make(chan struct{}, workers)}
+}
+
+// Start sends the queued operations once one of the requests is free. An error is returned once an operation has
+// failed in a way which would fail every issue, so that the run can stop.
+func (f *flusher) Start() error {
+	if err := f.stopErr(); err != nil {
+		return err
+	}
+
+	f.slots <- struct{}{}
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		defer func() { <-f.slots }()
+		f.add(f.batcher.Flush(f.ctx))
+	}()
+
+	return nil
+}
+
+// Wait waits for the requests in flight, sends the operations left over, and returns every operation which was sent,
+// each with its Err set if it failed
+func (f *flusher) Wait() []*linear.Operation {
+	f.wg.Wait()
+	f.add(f.batcher.Flush(f.ctx))
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ops
+}
+
+func (f *flusher) add(ops []*linear.Operation) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ops = append(f.ops, ops...)
+	for _, op := range ops {
+		if f.err == nil && op.Err != nil && !linear.Skippable(op.Err) {
+			f.err = op.Err
+		}
+	}
+}
+
+func (f *flusher) stopErr() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/jmartin127/linear-autolabeler/config"
+	"github.com/jmartin127/linear-autolabeler/linear"
+)
+
+// SyncLabels creates the labels declared in the team's config which the team does not have, and updates the color,
+// description and parent of those it does have to match the config. A parent which is not declared is created as an
+// empty label group.
+func SyncLabels(ctx context.Context, lc *linear.LinearClient, teamID string, teamConfig config.TeamConfig) error {
+	if len(teamConfig.Labels) == 0 {
+		return nil
+	}
+
+	existing, err := lc.GetTeamLabels(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	labelsByName := make(map[string]*linear.IssueLabelNode, len(existing))
+	for i := range existing {
+		labelsByName[existing[i].Name] = &existing[i]
+	}
+
+	// groups are created before the labels within them
+	groups := make(map[string]bool)
+	for _, l := range teamConfig.Labels {
+		if l.Parent != "" {
+			groups[l.Parent] = true
+		}
+	}
+	ordered := make([]config.Label, 0, len(teamConfig.Labels)+len(groups))
+	for _, l := range teamConfig.Labels {
+		if groups[l.Name] {
+			ordered = append(ordered, l)
+		}
+	}
+	for _, l := range teamConfig.Labels {
+		if !groups[l.Name] {
+			ordered = append(ordered, l)
+		}
+	}
+
+	for _, l := range ordered {
+		var parentID string
+		if l.Parent != "" {
+			parent, ok := labelsByName[l.Parent]
+			if !ok {
+				log.Printf("Creating label group %s...\n", l.Parent)
+				parent, err = lc.CreateLabel(ctx, linear.LabelCreateInput{TeamID: teamID, Name: l.Parent, IsGroup: true})
+				if err != nil {
+					return fmt.Errorf("creating label group %q: %w", l.Parent, err)
+				}
+				labelsByName[l.Parent] = parent
+			}
+			parentID = parent.ID
+		}
+
+		current, ok := labelsByName[l.Name]
+		if !ok {
+			log.Printf("Creating label %s...\n", l.Name)
+			created, err := lc.CreateLabel(ctx, linear.LabelCreateInput{
+				TeamID:      teamID,
+				Name:        l.Name,
+				Color:       l.Color,
+				Description: l.Description,
+				ParentID:    parentID,
+				IsGroup:     groups[l.Name],
+			})
+			if err != nil {
+				return fmt.Errorf("creating label %q: %w", l.Name, err)
+			}
+			labelsByName[l.Name] = created
+			continue
+		}
+
+		var update linear.LabelUpdateInput
+		var changed bool
+		if l.Color != "" && l.Color != current.Color {
+			update.Color = l.Color
+			changed = true
+		}
+		if l.Description != "" && l.Description != current.Description {
+			update.Description = l.Description
+			changed = true
+		}
+		if parentID != "" && (current.Parent == nil || current.Parent.ID != parentID) {
+			update.ParentID = parentID
+			changed = true
+		}
+		if changed {
+			log.Printf("Updating label %s...\n", l.Name)
+			if err := lc.UpdateLabel(ctx, current.ID, update); err != nil {
+				return fmt.Errorf("updating label %q: %w", l.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jmartin127/linear-autolabeler/actions"
+)
+
+// TicketPlan is what the rules did to a ticket, or in a dry run what they would have done
+type TicketPlan struct {
+	Ticket string `json:"ticket"`
+	Title  string `json:"title"`
+	// Rules are the names of the rules which matched
+	Rules        []string `json:"rules"`
+	AddLabels    []string `json:"addLabels,omitempty"`
+	RemoveLabels []string `json:"removeLabels,omitempty"`
+	// Comments are the comments posted, with their vars filled in
+	Comments []string `json:"comments,omitempty"`
+	// Changes are the other actions which changed the ticket, e.g. "set assignee Jeff"
+	Changes []string `json:"changes,omitempty"`
+}
+
+// changed returns true if anything was done to the ticket
+func (p *TicketPlan) changed() bool {
+	return len(p.AddLabels)+len(p.RemoveLabels)+len(p.Comments)+len(p.Changes) > 0
+}
+
+// addApplied records the actions which changed the ticket when a rule matched
+func (p *TicketPlan) addApplied(event *actions.Event) {
+	for _, a := range event.Applied {
+		switch a := a.(type) {
+		case *actions.AddLabel:
+			p.AddLabels = append(p.AddLabels, a.Label)
+		case *actions.RemoveLabel:
+			p.RemoveLabels = append(p.RemoveLabels, a.Label)
+		case *actions.Comment:
+			p.Comments = append(p.Comments, actions.RenderComment(a.Template, event.Vars))
+		default:
+			p.Changes = append(p.Changes, a.String())
+		}
+	}
+}
+
+// teamPlan is the plan of a team, as written by WritePlanJSON
+type teamPlan struct {
+	Workspace string       `json:"workspace"`
+	Team      string       `json:"team"`
+	Error     string       `json:"error,omitempty"`
+	Tickets   []TicketPlan `json:"tickets"`
+}
+
+// WritePlanText writes the tickets which rules matched in each team, and what was done to them, for people to read
+func WritePlanText(w io.Writer, results []Result) error {
+	var b strings.Builder
+	for _, r := range results {
+		fmt.Fprintf(&b, "Team %s (workspace %s)\n", r.Team, r.Workspace)
+		if r.Err != nil {
+			fmt.Fprintf(&b, "  error: %v\n", r.Err)
+		}
+		if len(r.Tickets) == 0 {
+			fmt.Fprintln(&b, "  no rules matched")
+		}
+		for _, t := range r.Tickets {
+			fmt.Fprintf(&b, "  %s %s\n", t.Ticket, t.Title)
+			for _, rule := range t.Rules {
+				fmt.Fprintf(&b, "    matched rule: %s\n", rule)
+			}
+			for _, l := range t.AddLabels {
+				fmt.Fprintf(&b, "    add label: %s\n", l)
+			}
+			for _, l := range t.RemoveLabels {
+				fmt.Fprintf(&b, "    remove label: %s\n", l)
+			}
+			for _, c := range t.Comments {
+				fmt.Fprintf(&b, "    comment: %s\n", c)
+			}
+			for _, c := range t.Changes {
+				fmt.Fprintf(&b, "    %s\n", c)
+			}
+			if !t.changed() {
+				fmt.Fprintln(&b, "    no changes")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WritePlanJSON writes the tickets which rules matched in each team, and what was done to them, as JSON
+func WritePlanJSON(w io.Writer, results []Result) error {
+	teams := make([]teamPlan, 0, len(results))
+	for _, r := range results {
+		t := teamPlan{Workspace: r.Workspace, Team: r.Team, Tickets: r.Tickets}
+		if r.Err != nil {
+			t.Error = r.Err.Error()
+		}
+		if t.Tickets == nil {
+			t.Tickets = []TicketPlan{}
+		}
+		teams = append(teams, t)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Teams []teamPlan `json:"teams"`
+	}{teams})
+}
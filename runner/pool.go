@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"bytes"
+	"log"
+
+	"github.com/jmartin127/linear-autolabeler/linear"
+)
+
+// issuePool runs the rules against up to workers issues at once. Each issue's log is held back until the issues added
+// before it are done, and their outcomes are handled in the order the issues were added, so the output and results
+// are the same as when processing one issue at a time.
+type issuePool struct {
+	workers  int
+	evaluate func(issue *linear.IssueNode, logger *log.Logger) (*TicketPlan, error)
+	report   func(issue *linear.IssueNode, plan *TicketPlan, err error) error
+
+	queue []*poolItem
+	err   error
+}
+
+// poolItem is an issue being evaluated by one of the workers
+type poolItem struct {
+	issue *linear.IssueNode
+	done  func(err error) error
+	logs  bytes.Buffer
+	plan  *TicketPlan
+	err   error
+	ready chan struct{}
+}
+
+func newIssuePool(workers int, evaluate func(*linear.IssueNode, *log.Logger) (*TicketPlan, error), report func(*linear.IssueNode, *TicketPlan, error) error) *issuePool {
+	if workers < 1 {
+		workers = 1
+	}
+	return &issuePool{workers: workers, evaluate: evaluate, report: report}
+}
+
+// Add starts evaluating the issue once a worker is free. done is called with the issue's error, if any, after it has
+// been reported. An error is returned once an issue has failed in a way which stops the run.
+func (p *issuePool) Add(issue *linear.IssueNode, done func(err error) error) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	// wait for the oldest issue once every worker is busy
+	for len(p.queue) >= p.workers {
+		if err := p.finishNext(); err != nil {
+			return err
+		}
+	}
+
+	item := &poolItem{issue: issue, done: done, ready: make(chan struct{})}
+	p.queue = append(p.queue, item)
+	go func() {
+		defer close(item.ready)
+		item.plan, item.err = p.evaluate(issue, log.New(&item.logs, log.Prefix(), log.Flags()))
+	}()
+
+	return nil
+}
+
+// Wait finishes every issue which was added. Once the run has been stopped, the issues still being evaluated are
+// waited for, but not reported.
+func (p *issuePool) Wait() error {
+	for len(p.queue) > 0 {
+		if p.err != nil {
+			<-p.queue[0].ready
+			p.queue = p.queue[1:]
+			continue
+		}
+		p.finishNext()
+	}
+
+	return p.err
+}
+
+// finishNext waits for the oldest issue, then prints its log and reports it
+func (p *issuePool) finishNext() error {
+	item := p.queue[0]
+	p.queue = p.queue[1:]
+	<-item.ready
+
+	log.Writer().Write(item.logs.Bytes())
+	err := p.report(item.issue, item.plan, item.err)
+	if err == nil && item.done != nil {
+		err = item.done(item.err)
+	}
+	if err != nil {
+		p.err = err
+	}
+
+	return err
+}
@@ -0,0 +1,364 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmartin127/linear-autolabeler/actions"
+	"github.com/jmartin127/linear-autolabeler/config"
+	"github.com/jmartin127/linear-autolabeler/linear"
+	"github.com/jmartin127/linear-autolabeler/rules"
+	"github.com/jmartin127/linear-autolabeler/snapshot"
+)
+
+// Result summarizes the run of a single team
+type Result struct {
+	Workspace string
+	Team      string
+	// Issues is the number of issues processed, and Skipped the number of those which could not be
+	Issues  int
+	Skipped int
+	// Matched is the number of times a rule matched an issue
+	Matched int
+	// Tickets are the tickets which a rule matched, or which were changed, in the order they were processed
+	Tickets []TicketPlan
+	// Err is set if the team could not be processed
+	Err error
+}
+
+// watermarkOverlap is how far before the start of a run the next run reads updates from, so that updates made while
+// the issues were being read, or hidden by clock skew, are not missed
+const watermarkOverlap = 5 * time.Minute
+
+// Options control how each team is run
+type Options struct {
+	PageSize int
+	// State is the local state of previous runs, or nil to read every active issue on every run
+	State *snapshot.File
+	// FullSyncInterval is how often every active issue is read again, rather than only those updated since the last run
+	FullSyncInterval time.Duration
+	// BatchSize is how many mutations are sent in each request. Values below two send each mutation on its own.
+	BatchSize int
+	// Workers is how many issues are processed at once, and how many batches of mutations are sent at once. The
+	// client's rate limit is shared between them.
+	Workers int
+	// DryRun evaluates the rules without sending any mutations, so the result's tickets are a plan of what a run would
+	// do. Labels declared in the config are not created or updated either.
+	DryRun bool
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+// RunTeam runs the team's rules against each of its issues. Issues which cannot be processed are skipped, while any
+// other error stops the team's run and is returned in the result.
+func RunTeam(ctx context.Context, lc *linear.LinearClient, workspace string, teamConfig config.TeamConfig, opts Options) Result {
+	result := Result{Workspace: workspace, Team: teamConfig.Team}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.DryRun {
+		lc = lc.DryRun()
+	}
+
+	// label changes and comments are queued and sent together, so whether an issue's mutations succeeded is only known
+	// once its batch is sent
+	client := lc
+	var batcher *linear.Batcher
+	var flushes *flusher
+	if opts.BatchSize > 1 && !opts.DryRun {
+		batcher = linear.NewBatcher(lc, opts.BatchSize)
+		client = lc.Batched(batcher)
+		flushes = newFlusher(ctx, batcher, opts.Workers)
+	}
+
+	prepared, err := prepareTeam(ctx, lc, client, teamConfig, opts.Now)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	teamID := prepared.team.ID
+	ruleSet := prepared.ruleSet
+	botUserIDs := prepared.botUserIDs
+
+	// only active issues are downloaded, as Linear leaves out those in the ignored states
+	query := linear.IssueQuery{
+		TeamID:            teamID,
+		ExcludeStates:     teamConfig.IgnoreIssueStates,
+		ExcludeStateTypes: make([]string, 0, len(teamConfig.IgnoreIssueStateTypes)),
+		PageSize:          opts.PageSize,
+	}
+	for _, stateType := range teamConfig.IgnoreIssueStateTypes {
+		query.ExcludeStateTypes = append(query.ExcludeStateTypes, strings.ToLower(stateType))
+	}
+
+	evaluate := func(issue *linear.IssueNode, logger *log.Logger) (*TicketPlan, error) {
+		plan, err := processIssue(ctx, client, ruleSet, issue, logger)
+		// the issue's mutations are only sent once it has been evaluated, so that they go in the same request
+		if batcher != nil {
+			batcher.Release(linear.TicketNumber(issue))
+		}
+		return plan, err
+	}
+	report := func(issue *linear.IssueNode, plan *TicketPlan, err error) error {
+		result.Issues++
+		result.Matched += len(plan.Rules)
+		if len(plan.Rules) > 0 || plan.changed() {
+			result.Tickets = append(result.Tickets, *plan)
+		}
+		if err != nil {
+			// move on to the next issue if this one cannot be processed, but stop if every issue would fail
+			if !linear.Skippable(err) {
+				return err
+			}
+			log.Printf("Skipping ticket %s: %v\n", linear.TicketNumber(issue), err)
+			result.Skipped++
+		}
+		if flushes != nil && batcher.Full() {
+			return flushes.Start()
+		}
+		return nil
+	}
+	issues := newIssuePool(opts.Workers, evaluate, report)
+
+	var teamState *snapshot.Team
+	var startedAt time.Time
+	var fullSync bool
+	if opts.State == nil {
+		result.Err = lc.Issues(ctx, query, func(issue *linear.IssueNode) error {
+			return issues.Add(issue, nil)
+		})
+		if err := issues.Wait(); result.Err == nil {
+			result.Err = err
+		}
+	} else {
+		teamState = opts.State.Team(workspace, teamID)
+		startedAt = opts.Now()
+		fullSync = teamState.Watermark.IsZero() || startedAt.Sub(teamState.LastFullSync) >= opts.FullSyncInterval
+		result.Err = syncTeam(ctx, lc, teamConfig, teamState, query, fullSync, botUserIDs, issues)
+	}
+
+	// send the mutations left over, even if the run stopped early, as they were for issues which were processed. Issues
+	// whose mutations failed are skipped, however many of their mutations failed. Every failed operation is recorded,
+	// even once one has failed in a way which stops the run, so that the state does not keep its changes.
+	failedTickets := make(map[string]bool)
+	if flushes != nil {
+		for _, op := range flushes.Wait() {
+			if op.Err == nil || failedTickets[op.Issue] {
+				continue
+			}
+			failedTickets[op.Issue] = true
+			if !linear.Skippable(op.Err) {
+				if result.Err == nil {
+					result.Err = op.Err
+				}
+				continue
+			}
+			log.Printf("Skipping ticket %s: %v\n", op.Issue, op.Err)
+			result.Skipped++
+		}
+	}
+
+	// the state holds the changes which the failed mutations would have made, so drop those issues until they are read
+	// again
+	if teamState != nil {
+		for ticketNumber := range failedTickets {
+			teamState.DeleteTicket(ticketNumber)
+		}
+
+		// only move the watermark once every mutation has been sent, so that a team which failed reads its updates
+		// again on the next run
+		if result.Err == nil {
+			teamState.Watermark = startedAt.Add(-watermarkOverlap)
+			if fullSync {
+				teamState.LastFullSync = startedAt
+			}
+		}
+	}
+
+	return result
+}
+
+// preparedTeam is a team whose rules are ready to run
+type preparedTeam struct {
+	team    *linear.TeamNode
+	ruleSet []*rules.Rule
+	// botUserIDs are the users whose comments are not activity on an issue
+	botUserIDs []string
+}
+
+// prepareTeam finds the team, creates the labels declared in its config, checks the config against the team, and
+// builds its rules. The rules' actions make their changes with client, while lc is used for everything else.
+func prepareTeam(ctx context.Context, lc, client *linear.LinearClient, teamConfig config.TeamConfig, now func() time.Time) (*preparedTeam, error) {
+	// find the team
+	team, err := lc.FindTeam(ctx, teamConfig.Team)
+	if err != nil {
+		return nil, err
+	}
+	teamID := team.ID
+	log.Printf("Found team %s (%s)\n", team.Name, team.Key)
+
+	// create the labels declared in the config before checking the names used by the rules
+	if err := SyncLabels(ctx, lc, teamID, teamConfig); err != nil {
+		return nil, err
+	}
+
+	// check the config only names states and labels the team has
+	if err := ValidateTeam(ctx, lc, teamID, teamConfig); err != nil {
+		return nil, err
+	}
+
+	// build the rules from the configured jobs
+	loc, err := time.LoadLocation(teamConfig.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	// comments made with the token, or by the other configured bots, are not activity on an issue
+	viewer, err := lc.Viewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	botUserIDs := append([]string{viewer.ID}, teamConfig.BotUserIDs...)
+
+	actionEnv := &actions.Env{Client: client, TeamID: teamID, Location: loc, Now: now}
+	ruleSet, err := rules.Build(ctx, teamConfig.Jobs, rules.Env{Location: loc, Now: now, BotUserIDs: botUserIDs, Actions: actionEnv})
+	if err != nil {
+		return nil, err
+	}
+
+	return &preparedTeam{team: team, ruleSet: ruleSet, botUserIDs: botUserIDs}, nil
+}
+
+// CheckTeam checks the config against the team, as a run would before processing any issues, without changing
+// anything in Linear
+func CheckTeam(ctx context.Context, lc *linear.LinearClient, teamConfig config.TeamConfig) error {
+	lc = lc.DryRun()
+	_, err := prepareTeam(ctx, lc, lc, teamConfig, time.Now)
+	return err
+}
+
+// syncTeam reads the team's issues into its local state, and processes every active issue in the state. Every active
+// issue is read on a full sync, which happens on the first run and once the full sync interval has passed, while other
+// runs only read the issues updated since the watermark. Issues which are no longer active are dropped from the state.
+// The caller moves the watermark once the issues' mutations have been sent.
+func syncTeam(ctx context.Context, lc *linear.LinearClient, teamConfig config.TeamConfig, teamState *snapshot.Team, query linear.IssueQuery, fullSync bool, botUserIDs []string, issues *issuePool) error {
+	// issues which were read are processed as loaded, while the rest are restored from the state
+	loaded := make(map[string]*linear.IssueNode)
+	if fullSync {
+		log.Println("Reading every active issue")
+		teamState.Issues = make(map[string]*snapshot.Issue)
+	} else {
+		// updated issues are read whatever their state, so that those which are no longer active are dropped
+		log.Printf("Reading issues updated since %s\n", teamState.Watermark.Format(time.RFC3339))
+		query = linear.IssueQuery{TeamID: query.TeamID, UpdatedAfter: teamState.Watermark, PageSize: query.PageSize}
+	}
+	err := lc.Issues(ctx, query, func(issue *linear.IssueNode) error {
+		if teamConfig.ShouldIgnoreState(issue.State) {
+			delete(teamState.Issues, issue.ID)
+			return nil
+		}
+		teamState.Issues[issue.ID] = snapshot.FromIssue(issue, botUserIDs)
+		loaded[issue.ID] = issue
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// time passes for every active issue, so the rules are run against all of them, not only those which changed
+	for _, stored := range teamState.SortedIssues() {
+		issue, ok := loaded[stored.ID]
+		if !ok {
+			issue = stored.IssueNode()
+		}
+
+		id := stored.ID
+		err := issues.Add(issue, func(err error) error {
+			switch {
+			case errors.Is(err, linear.ErrNotFound):
+				// the issue was deleted or archived
+				delete(teamState.Issues, id)
+			case err != nil && !linear.Skippable(err):
+				return err
+			default:
+				// keep the changes made by the actions
+				teamState.Issues[id] = snapshot.FromIssue(issue, botUserIDs)
+			}
+			return nil
+		})
+		if err != nil {
+			break
+		}
+	}
+	return issues.Wait()
+}
+
+// processIssue runs every rule against the issue, applying the actions of those which match, and returns the rules
+// which matched and what was done to the issue. Labels added by the rules which no longer match are removed. What is
+// done to the issue is logged to the logger.
+func processIssue(ctx context.Context, lc *linear.LinearClient, ruleSet []*rules.Rule, issue *linear.IssueNode, logger *log.Logger) (*TicketPlan, error) {
+	ticketNumber := linear.TicketNumber(issue)
+	plan := &TicketPlan{Ticket: ticketNumber, Title: issue.Title, Rules: make([]string, 0)}
+
+	// several rules may share a label, so only remove a label if none of its rules matched
+	matchedLabels := make(map[string]bool)
+	managedLabels := make(map[string]*actions.AddLabel)
+	for _, rule := range ruleSet {
+		ruleLabels := actions.ManagedLabels(rule.Actions)
+		for _, l := range ruleLabels {
+			managedLabels[l.LabelID] = l
+		}
+
+		result, err := rule.Filter.Match(ctx, issue)
+		if err != nil {
+			return plan, err
+		}
+		if !result.Matched {
+			continue
+		}
+
+		plan.Rules = append(plan.Rules, rule.Name)
+		for _, l := range ruleLabels {
+			matchedLabels[l.LabelID] = true
+		}
+		logger.Printf("Ticket: %s, Matched Rule: %s\n", ticketNumber, rule.Name)
+		event := &actions.Event{
+			Issue: issue,
+			Log:   logger,
+			Vars: map[string]string{
+				"slaExceeding": result.Exceeding.String(),
+				"sla":          result.SLA.String(),
+			},
+		}
+		err = actions.Run(ctx, rule.Actions, event)
+		plan.addApplied(event)
+		if err != nil {
+			return plan, fmt.Errorf("ticket %s, rule %q: %w", ticketNumber, rule.Name, err)
+		}
+	}
+
+	// remove the labels in a fixed order, so that runs log the same lines
+	labelIDs := make([]string, 0, len(managedLabels))
+	for labelID := range managedLabels {
+		if !matchedLabels[labelID] {
+			labelIDs = append(labelIDs, labelID)
+		}
+	}
+	sort.Strings(labelIDs)
+	for _, labelID := range labelIDs {
+		removed, err := lc.RemoveLabelFromTicket(ctx, issue, labelID)
+		if err != nil {
+			return plan, err
+		}
+		if removed {
+			logger.Printf("Ticket: %s, Removed Label: %s\n", ticketNumber, managedLabels[labelID].Label)
+			plan.RemoveLabels = append(plan.RemoveLabels, managedLabels[labelID].Label)
+		}
+	}
+
+	return plan, nil
+}
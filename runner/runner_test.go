@@ -0,0 +1,379 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmartin127/linear-autolabeler/config"
+	"github.com/jmartin127/linear-autolabeler/linear"
+	"github.com/jmartin127/linear-autolabeler/linear/lineartest"
+	"github.com/jmartin127/linear-autolabeler/snapshot"
+)
+
+// testTeam is a team on the fake server with Todo, In Progress and Done states, and a rule which labels and comments
+// on issues which have been in Todo for longer than a working day
+type testTeam struct {
+	srv    *lineartest.Server
+	team   *lineartest.Team
+	todo   *lineartest.State
+	doing  *lineartest.State
+	done   *lineartest.State
+	config config.TeamConfig
+}
+
+func newTestTeam(t *testing.T) *testTeam {
+	t.Helper()
+	srv := lineartest.NewServer()
+	t.Cleanup(srv.Close)
+
+	team := srv.AddTeam("INT", "Integrations")
+	tt := &testTeam{
+		srv:   srv,
+		team:  team,
+		todo:  srv.AddState(team, "Todo", linear.StateTypeUnstarted),
+		doing: srv.AddState(team, "In Progress", linear.StateTypeStarted),
+		done:  srv.AddState(team, "Done", linear.StateTypeCompleted),
+		config: config.TeamConfig{
+			Team:                  "INT",
+			TimeZone:              "America/Denver",
+			IgnoreIssueStateTypes: []string{linear.StateTypeCompleted},
+			Labels:                []config.Label{{Name: "ExceedsSLA", Color: "#eb5757"}},
+			Jobs: []config.Job{{
+				Name:   "Todo for too long",
+				Filter: []config.Filter{{Type: config.FilterTypeSLA, CurrentState: "Todo", LongerThan: 8 * time.Hour}},
+				Action: config.Action{Label: "ExceedsSLA", Comment: "Exceeds the SLA of ${sla} by ${slaExceeding}"},
+			}},
+		},
+	}
+	return tt
+}
+
+func (tt *testTeam) run(t *testing.T, opts Options) Result {
+	t.Helper()
+	opts.Now = tt.srv.Now
+	if opts.PageSize == 0 {
+		opts.PageSize = 2
+	}
+	return RunTeam(context.Background(), tt.srv.Client(linear.WithRetryPolicy(linear.RetryPolicy{})), config.DefaultWorkspace, tt.config, opts)
+}
+
+// assertLabeled checks the issue has the label and the single comment of the rule, or has neither
+func (tt *testTeam) assertLabeled(t *testing.T, ticketNumber string, labeled bool) {
+	t.Helper()
+	issue, ok := tt.srv.Issue(ticketNumber)
+	if !ok {
+		t.Fatalf("%s does not exist", ticketNumber)
+	}
+
+	wantLabels, wantComments := []string{}, 0
+	if labeled {
+		wantLabels, wantComments = []string{"ExceedsSLA"}, 1
+	}
+	if got := tt.srv.LabelNames(&issue); !reflect.DeepEqual(got, wantLabels) {
+		t.Errorf("%s has labels %v, want %v", ticketNumber, got, wantLabels)
+	}
+	if len(issue.Comments) != wantComments {
+		t.Errorf("%s has %d comments, want %d", ticketNumber, len(issue.Comments), wantComments)
+	}
+}
+
+// mutationCounts counts the mutations received by name
+func mutationCounts(srv *lineartest.Server) map[string]int {
+	counts := make(map[string]int)
+	for _, m := range srv.Mutations() {
+		counts[m.Name]++
+	}
+	return counts
+}
+
+func TestRunTeamLabelsAndCommentsOnce(t *testing.T) {
+	for _, batchSize := range []int{1, 3} {
+		t.Run(fmt.Sprintf("batch size %d", batchSize), func(t *testing.T) {
+			tt := newTestTeam(t)
+			for i := 0; i < 5; i++ {
+				tt.srv.AddIssue(tt.team, fmt.Sprintf("Issue %d", i), tt.todo)
+			}
+			tt.srv.AddIssue(tt.team, "Started", tt.doing)
+			tt.srv.Advance(48 * time.Hour)
+
+			opts := Options{BatchSize: batchSize, Workers: 4}
+			result := tt.run(t, opts)
+			if result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if result.Issues != 6 || result.Matched != 5 {
+				t.Errorf("processed %d issues with %d matches, want 6 with 5", result.Issues, result.Matched)
+			}
+			for i := 1; i <= 5; i++ {
+				tt.assertLabeled(t, fmt.Sprintf("INT-%d", i), true)
+			}
+			tt.assertLabeled(t, "INT-6", false)
+
+			// each issue's comment follows its label, however the batches were sent
+			labeled := make(map[string]bool)
+			for _, m := range tt.srv.Mutations() {
+				switch m.Name {
+				case "issueAddLabel":
+					issue, _ := tt.srv.Issue(m.Args["id"].(string))
+					labeled[issue.ID] = true
+				case "commentCreate":
+					if issueID := m.Args["input"].(map[string]interface{})["issueId"].(string); !labeled[issueID] {
+						t.Errorf("comment on %s was sent before its label", issueID)
+					}
+				}
+			}
+
+			// the issues still match, but already have the label, so nothing is done to them
+			tt.srv.ResetMutations()
+			tt.srv.Advance(time.Hour)
+			if result := tt.run(t, opts); result.Err != nil {
+				t.Fatal(result.Err)
+			}
+			if got := tt.srv.Mutations(); len(got) != 0 {
+				t.Errorf("second run sent %v, want no mutations", got)
+			}
+			for i := 1; i <= 5; i++ {
+				tt.assertLabeled(t, fmt.Sprintf("INT-%d", i), true)
+			}
+		})
+	}
+}
+
+func TestRunTeamRemovesLabelWhenRuleStopsMatching(t *testing.T) {
+	tt := newTestTeam(t)
+	moved := tt.srv.AddIssue(tt.team, "Moved", tt.todo)
+	tt.srv.AddIssue(tt.team, "Stays", tt.todo)
+	tt.srv.Advance(48 * time.Hour)
+
+	opts := Options{BatchSize: 5, Workers: 1}
+	if result := tt.run(t, opts); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	tt.assertLabeled(t, "INT-1", true)
+
+	tt.srv.MoveIssue(moved, tt.doing)
+	tt.srv.ResetMutations()
+	if result := tt.run(t, opts); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	issue, _ := tt.srv.Issue("INT-1")
+	if got := tt.srv.LabelNames(&issue); len(got) != 0 {
+		t.Errorf("INT-1 has labels %v, want none", got)
+	}
+	if got, want := mutationCounts(tt.srv), map[string]int{"issueRemoveLabel": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sent mutations %v, want %v", got, want)
+	}
+	tt.assertLabeled(t, "INT-2", true)
+}
+
+func TestRunTeamIncrementalSync(t *testing.T) {
+	tt := newTestTeam(t)
+	finished := tt.srv.AddIssue(tt.team, "Finished", tt.todo)
+	tt.srv.AddIssue(tt.team, "Untouched", tt.todo)
+
+	state := &snapshot.File{Teams: make(map[string]*snapshot.Team)}
+	opts := Options{State: state, FullSyncInterval: 72 * time.Hour, BatchSize: 5, Workers: 2}
+	firstRun := tt.srv.Now()
+	if result := tt.run(t, opts); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	teamState := state.Team(config.DefaultWorkspace, tt.team.ID)
+	if len(teamState.Issues) != 2 || !teamState.LastFullSync.Equal(firstRun) {
+		t.Fatalf("after the first run the state has %d issues and a full sync at %s, want 2 at %s", len(teamState.Issues), teamState.LastFullSync, firstRun)
+	}
+	if want := firstRun.Add(-watermarkOverlap); !teamState.Watermark.Equal(want) {
+		t.Errorf("watermark is %s, want %s", teamState.Watermark, want)
+	}
+
+	// before the next full sync, so only the new and updated issues are read, but by the next morning the untouched
+	// issue exceeds its SLA
+	tt.srv.Advance(3 * time.Hour)
+	tt.srv.MoveIssue(finished, tt.done)
+	tt.srv.AddIssue(tt.team, "New", tt.todo)
+	tt.srv.Advance(23 * time.Hour)
+	secondRun := tt.srv.Now()
+	result := tt.run(t, opts)
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result.Issues != 2 {
+		t.Errorf("processed %d issues, want the untouched and new issues", result.Issues)
+	}
+	if _, ok := teamState.Issues[finished.ID]; ok {
+		t.Error("finished issue is still in the state")
+	}
+	if !teamState.LastFullSync.Equal(firstRun) {
+		t.Errorf("an incremental run moved the last full sync to %s", teamState.LastFullSync)
+	}
+	if want := secondRun.Add(-watermarkOverlap); !teamState.Watermark.Equal(want) {
+		t.Errorf("watermark is %s, want %s", teamState.Watermark, want)
+	}
+	tt.assertLabeled(t, "INT-1", false)
+	tt.assertLabeled(t, "INT-2", true)
+	tt.assertLabeled(t, "INT-3", false)
+}
+
+func TestRunTeamSetStateKeepsStateType(t *testing.T) {
+	tt := newTestTeam(t)
+	// the first job starts the issues which are overdue, and the second labels the started issues
+	tt.config.Jobs = []config.Job{
+		{
+			Name:   "start overdue issues",
+			Filter: []config.Filter{{Type: config.FilterTypeSLA, CurrentState: "Todo", LongerThan: 8 * time.Hour}},
+			Action: config.Action{Type: config.ActionTypeSetState, State: "In Progress"},
+		},
+		{
+			Name:   "label started issues",
+			Filter: []config.Filter{{Type: config.FilterTypeStateIs, StateType: linear.StateTypeStarted}},
+			Action: config.Action{Label: "ExceedsSLA", Comment: "Started"},
+		},
+	}
+	tt.srv.AddIssue(tt.team, "Overdue", tt.todo)
+	tt.srv.Advance(48 * time.Hour)
+	tt.srv.AddIssue(tt.team, "New", tt.todo)
+
+	if result := tt.run(t, Options{BatchSize: 5, Workers: 1}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	tt.assertLabeled(t, "INT-1", true)
+	tt.assertLabeled(t, "INT-2", false)
+	if issue, _ := tt.srv.Issue("INT-1"); issue.StateID != tt.doing.ID {
+		t.Errorf("INT-1 is in state %s, want In Progress", issue.StateID)
+	}
+}
+
+func TestRunTeamLongHistory(t *testing.T) {
+	tt := newTestTeam(t)
+	review := tt.srv.AddState(tt.team, "In Review", linear.StateTypeStarted)
+	tt.config.Jobs[0].Filter[0].EnteredState = "In Review"
+	recent := tt.srv.AddIssue(tt.team, "Reviewed recently", tt.todo)
+	stale := tt.srv.AddIssue(tt.team, "Reviewed long ago", tt.todo)
+
+	// each issue is reviewed once, then has more history than fits in a page, ending back in Todo. Linear lists the
+	// most recent history first, so the review is on the last page.
+	reviewed := func(issue *lineartest.Issue) {
+		tt.srv.MoveIssue(issue, review)
+		for i := 0; i < 60; i++ {
+			tt.srv.MoveIssue(issue, tt.doing)
+			tt.srv.MoveIssue(issue, tt.todo)
+		}
+	}
+	reviewed(stale)
+	tt.srv.Advance(48 * time.Hour)
+	reviewed(recent)
+
+	if result := tt.run(t, Options{BatchSize: 5, Workers: 2}); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	// without the review, the SLA would be measured from when the issue was created
+	tt.assertLabeled(t, "INT-1", false)
+	tt.assertLabeled(t, "INT-2", true)
+}
+
+func TestRunTeamBatchFailures(t *testing.T) {
+	t.Run("unauthorized", func(t *testing.T) {
+		tt := newTestTeam(t)
+		for i := 0; i < 4; i++ {
+			tt.srv.AddIssue(tt.team, fmt.Sprintf("Issue %d", i), tt.todo)
+		}
+
+		// every mutation is rejected, while the issues can still be read
+		failMutations := true
+		handler := tt.srv.Config.Handler
+		tt.srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if failMutations && strings.Contains(string(body), "mutation") {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"errors":[{"message":"Authentication required, not authenticated"}]}`))
+				return
+			}
+			handler.ServeHTTP(w, r)
+		})
+
+		// the labels exist, as declared labels are created with mutations
+		tt.srv.AddLabel(tt.team, "ExceedsSLA")
+		tt.config.Labels = nil
+
+		state := &snapshot.File{Teams: make(map[string]*snapshot.Team)}
+		opts := Options{State: state, FullSyncInterval: 24 * time.Hour, BatchSize: 2, Workers: 2}
+		tt.srv.Advance(48 * time.Hour)
+		result := tt.run(t, opts)
+		if !errors.Is(result.Err, linear.ErrUnauthorized) {
+			t.Fatalf("run failed with %v, want an unauthorized error", result.Err)
+		}
+		teamState := state.Team(config.DefaultWorkspace, tt.team.ID)
+		if !teamState.Watermark.IsZero() || len(teamState.Issues) != 0 {
+			t.Errorf("failed run left a watermark of %s and %d issues, want neither", teamState.Watermark, len(teamState.Issues))
+		}
+
+		// the next run makes the changes which failed
+		failMutations = false
+		tt.srv.Advance(time.Hour)
+		if result := tt.run(t, opts); result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		for i := 1; i <= 4; i++ {
+			tt.assertLabeled(t, fmt.Sprintf("INT-%d", i), true)
+		}
+	})
+
+	t.Run("deleted issue", func(t *testing.T) {
+		tt := newTestTeam(t)
+		var deleted *lineartest.Issue
+		for i := 0; i < 5; i++ {
+			issue := tt.srv.AddIssue(tt.team, fmt.Sprintf("Issue %d", i), tt.todo)
+			if i == 1 {
+				deleted = issue
+			}
+		}
+
+		// the first run reads the issues before they exceed the SLA, and the second restores them from the state
+		state := &snapshot.File{Teams: make(map[string]*snapshot.Team)}
+		opts := Options{State: state, FullSyncInterval: 72 * time.Hour, BatchSize: 5, Workers: 1}
+		if result := tt.run(t, opts); result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		tt.srv.DeleteIssue(deleted)
+		tt.srv.Advance(26 * time.Hour)
+		secondRun := tt.srv.Now()
+		tt.srv.ResetMutations()
+
+		result := tt.run(t, opts)
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		if result.Skipped != 1 {
+			t.Errorf("skipped %d issues, want the deleted issue", result.Skipped)
+		}
+		for _, ticketNumber := range []string{"INT-1", "INT-3", "INT-4", "INT-5"} {
+			tt.assertLabeled(t, ticketNumber, true)
+		}
+		// the deleted issue's comment was not sent once its label failed, while the other issues' mutations after it
+		// were sent again, and none was sent twice
+		if got, want := mutationCounts(tt.srv), map[string]int{"issueAddLabel": 5, "commentCreate": 4}; !reflect.DeepEqual(got, want) {
+			t.Errorf("sent mutations %v, want %v", got, want)
+		}
+
+		teamState := state.Team(config.DefaultWorkspace, tt.team.ID)
+		if _, ok := teamState.Issues[deleted.ID]; ok {
+			t.Error("deleted issue is still in the state")
+		}
+		if len(teamState.Issues) != 4 {
+			t.Errorf("state has %d issues, want 4", len(teamState.Issues))
+		}
+		if want := secondRun.Add(-watermarkOverlap); !teamState.Watermark.Equal(want) {
+			t.Errorf("watermark is %s, want %s", teamState.Watermark, want)
+		}
+	})
+}
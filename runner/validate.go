@@ -0,0 +1,144 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jmartin127/linear-autolabeler/config"
+	"github.com/jmartin127/linear-autolabeler/linear"
+)
+
+// maxSuggestionDistance is how many edits away a valid name may be to be suggested
+const maxSuggestionDistance = 5
+
+// UnknownName is a workflow state or label named in the config which the team does not have
+type UnknownName struct {
+	Kind        string
+	Reference   config.Reference
+	Suggestions []string
+}
+
+// ValidationError lists every workflow state and label named in a team's config which the team does not have
+type ValidationError struct {
+	Team    string
+	Unknown []UnknownName
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "team %s: %d unknown names in the config:", e.Team, len(e.Unknown))
+	for _, u := range e.Unknown {
+		fmt.Fprintf(&b, "\n  %s %q (%s)", u.Kind, u.Reference.Name, u.Reference.Where)
+		if len(u.Suggestions) > 0 {
+			fmt.Fprintf(&b, ", did you mean %q?", strings.Join(u.Suggestions, `" or "`))
+		}
+	}
+	return b.String()
+}
+
+// ValidateTeam checks every workflow state and label named in the team's config against the team's actual workflow
+// states and labels. A typo would otherwise mean a rule silently never matches.
+func ValidateTeam(ctx context.Context, lc *linear.LinearClient, teamID string, teamConfig config.TeamConfig) error {
+	states, err := lc.GetWorkflowStates(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	stateNames := make([]string, 0, len(states))
+	for _, s := range states {
+		stateNames = append(stateNames, s.Name)
+	}
+
+	labels, err := lc.GetTeamLabels(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	labelNames := make([]string, 0, len(labels))
+	for _, l := range labels {
+		labelNames = append(labelNames, l.Name)
+	}
+
+	validationErr := &ValidationError{Team: teamConfig.Team}
+	validationErr.Unknown = append(validationErr.Unknown, unknownNames("state", teamConfig.StateReferences(), stateNames)...)
+	validationErr.Unknown = append(validationErr.Unknown, unknownNames("label", teamConfig.LabelReferences(), labelNames)...)
+	if len(validationErr.Unknown) > 0 {
+		return validationErr
+	}
+
+	return nil
+}
+
+func unknownNames(kind string, refs []config.Reference, valid []string) []UnknownName {
+	validSet := make(map[string]bool, len(valid))
+	for _, v := range valid {
+		validSet[v] = true
+	}
+
+	unknown := make([]UnknownName, 0)
+	for _, ref := range refs {
+		if validSet[ref.Name] {
+			continue
+		}
+		unknown = append(unknown, UnknownName{
+			Kind:        kind,
+			Reference:   ref,
+			Suggestions: closestNames(ref.Name, valid),
+		})
+	}
+	return unknown
+}
+
+// closestNames returns the valid names nearest to the name, ignoring case, as long as they are close enough to be a
+// plausible typo
+func closestNames(name string, valid []string) []string {
+	best := maxSuggestionDistance + 1
+	closest := make([]string, 0)
+	for _, v := range valid {
+		d := editDistance(strings.ToLower(name), strings.ToLower(v))
+		if d > best || d > len(v)/2 {
+			continue
+		}
+		if d < best {
+			best = d
+			closest = closest[:0]
+		}
+		closest = append(closest, v)
+	}
+	sort.Strings(closest)
+	return closest
+}
+
+// editDistance is the Levenshtein distance between the strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
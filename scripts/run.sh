@@ -1,3 +1,3 @@
 #!/usr/bin/env bash
 
-/usr/local/bin/docker run linear-autolabeler:0.15 $1
+/usr/local/bin/docker run -e LINEAR_TOKEN="$1" linear-autolabeler:0.15 "${@:2}"
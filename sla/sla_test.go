@@ -0,0 +1,72 @@
+package sla
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBusinessDurationBetweenTimes(t *testing.T) {
+	loc, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2022, month, day, hour, 0, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       time.Duration
+	}{
+		{"within a day", at(time.June, 6, 10), at(time.June, 6, 12), 2 * time.Hour},
+		{"before and after the working day", at(time.June, 6, 7), at(time.June, 6, 20), 8 * time.Hour},
+		{"over a night", at(time.June, 6, 16), at(time.June, 7, 10), 2 * time.Hour},
+		{"over a weekend", at(time.June, 10, 16), at(time.June, 13, 10), 2 * time.Hour},
+		{"within a weekend", at(time.June, 11, 10), at(time.June, 12, 16), 0},
+		{"over a holiday", at(time.July, 1, 16), at(time.July, 5, 10), 2 * time.Hour},
+		{"on a holiday", at(time.July, 4, 9), at(time.July, 4, 17), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BusinessDurationBetweenTimes(tt.start, tt.end); got != tt.want {
+				t.Errorf("BusinessDurationBetweenTimes() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExceedsSLAInBusinessHours(t *testing.T) {
+	loc, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	monday := time.Date(2022, time.June, 6, 9, 0, 0, 0, loc)
+
+	tests := []struct {
+		name          string
+		refTime, now  time.Time
+		sla           time.Duration
+		wantExceeds   bool
+		wantExceeding time.Duration
+	}{
+		{"within the SLA", monday, monday.Add(4 * time.Hour), 8 * time.Hour, false, 0},
+		{"exactly the SLA", monday, monday.Add(8 * time.Hour), 8 * time.Hour, false, 0},
+		{"exceeds the SLA", monday, monday.Add(28 * time.Hour), 8 * time.Hour, true, 4 * time.Hour},
+		{"in another time zone", monday.UTC(), monday.Add(28 * time.Hour).UTC(), 8 * time.Hour, true, 4 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exceeds, exceeding, sla := ExceedsSLAInBusinessHours(tt.refTime, tt.now, loc, tt.sla)
+			if exceeds != tt.wantExceeds {
+				t.Fatalf("ExceedsSLAInBusinessHours() exceeds = %t, want %t", exceeds, tt.wantExceeds)
+			}
+			if !exceeds {
+				return
+			}
+			if exceeding != tt.wantExceeding || sla != tt.sla {
+				t.Errorf("ExceedsSLAInBusinessHours() = %s over %s, want %s over %s", exceeding, sla, tt.wantExceeding, tt.sla)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Package snapshot stores the issues read by previous runs in a local file, so that later runs only need to read the
+// issues which have been updated since.
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jmartin127/linear-autolabeler/linear"
+)
+
+// File is the local state of every team, keyed by workspace and team ID
+type File struct {
+	Teams map[string]*Team `json:"teams"`
+}
+
+// Team is the local state of a team. Watermark is the time up to which updates to its issues have been read, and
+// LastFullSync when every active issue was last read.
+type Team struct {
+	Watermark    time.Time         `json:"watermark"`
+	LastFullSync time.Time         `json:"lastFullSync"`
+	Issues       map[string]*Issue `json:"issues"`
+}
+
+// Issue holds the fields of an active issue which the rules use. Comments are reduced to the time of the last one
+// not made by a bot.
+type Issue struct {
+	ID            string          `json:"id"`
+	TeamKey       string          `json:"teamKey"`
+	Number        int             `json:"number"`
+	Title         string          `json:"title"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+	Priority      int             `json:"priority,omitempty"`
+	DueDate       string          `json:"dueDate,omitempty"`
+	Assignee      linear.Assignee `json:"assignee"`
+	State         linear.State    `json:"state"`
+	History       []Transition    `json:"history,omitempty"`
+	Labels        []Label         `json:"labels,omitempty"`
+	SubscriberIDs []string        `json:"subscriberIds,omitempty"`
+	LastCommentAt time.Time       `json:"lastCommentAt"`
+}
+
+// Transition is a move of an issue from one workflow state to another
+type Transition struct {
+	At   time.Time            `json:"at"`
+	From linear.WorkflowState `json:"from"`
+	To   linear.WorkflowState `json:"to"`
+}
+
+type Label struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Load reads the state file at the given path, or returns an empty state if there is no file yet
+func Load(path string) (*File, error) {
+	f := &File{Teams: make(map[string]*Team)}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("parsing state file %s: %w", path, err)
+	}
+	if f.Teams == nil {
+		f.Teams = make(map[string]*Team)
+	}
+
+	return f, nil
+}
+
+// Save writes the state file to the given path. The file is replaced in one step, so that a run which is stopped
+// part way through does not leave a truncated file behind.
+func (f *File) Save(path string) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Team returns the state of the team, creating it if the team has not been run before
+func (f *File) Team(workspace, teamID string) *Team {
+	key := workspace + "/" + teamID
+	t, ok := f.Teams[key]
+	if !ok {
+		t = &Team{}
+		f.Teams[key] = t
+	}
+	if t.Issues == nil {
+		t.Issues = make(map[string]*Issue)
+	}
+
+	return t
+}
+
+// SortedIssues returns the team's issues in the order they were created, as Linear lists them
+func (t *Team) SortedIssues() []*Issue {
+	issues := make([]*Issue, 0, len(t.Issues))
+	for _, i := range t.Issues {
+		issues = append(issues, i)
+	}
+	sort.Slice(issues, func(a, b int) bool {
+		if issues[a].CreatedAt.Equal(issues[b].CreatedAt) {
+			return issues[a].Number < issues[b].Number
+		}
+		return issues[a].CreatedAt.Before(issues[b].CreatedAt)
+	})
+
+	return issues
+}
+
+// DeleteTicket drops the issue with the ticket number, e.g. INT-123, from the state
+func (t *Team) DeleteTicket(ticketNumber string) {
+	for id, i := range t.Issues {
+		if fmt.Sprintf("%s-%d", i.TeamKey, i.Number) == ticketNumber {
+			delete(t.Issues, id)
+		}
+	}
+}
+
+// FromIssue converts an issue loaded from Linear, ignoring the comments made by the bots
+func FromIssue(issue *linear.IssueNode, botUserIDs []string) *Issue {
+	i := &Issue{
+		ID:            issue.ID,
+		TeamKey:       issue.TeamName.Key,
+		Number:        issue.Number,
+		Title:         issue.Title,
+		CreatedAt:     issue.CreatedAt,
+		UpdatedAt:     issue.UpdatedAt,
+		Priority:      issue.Priority,
+		DueDate:       issue.DueDate,
+		Assignee:      issue.Assignee,
+		State:         issue.State,
+		LastCommentAt: linear.GetLastTimeIssueWasCommentedOn(issue, botUserIDs),
+	}
+	for _, h := range issue.IssueHistory.Nodes {
+		i.History = append(i.History, Transition{At: h.CreatedAt, From: h.FromState, To: h.ToState})
+	}
+	for _, l := range issue.IssueLabels.Nodes {
+		i.Labels = append(i.Labels, Label{ID: l.ID, Name: l.Name})
+	}
+	for _, u := range issue.Subscribers.Nodes {
+		i.SubscriberIDs = append(i.SubscriberIDs, u.ID)
+	}
+
+	return i
+}
+
+// IssueNode converts the issue back into the form loaded from Linear, so that the rules can be run against it. The
+// last comment not made by a bot stands in for all of the issue's comments.
+func (i *Issue) IssueNode() *linear.IssueNode {
+	issue := &linear.IssueNode{
+		ID:        i.ID,
+		Number:    i.Number,
+		Title:     i.Title,
+		CreatedAt: i.CreatedAt,
+		UpdatedAt: i.UpdatedAt,
+		Priority:  i.Priority,
+		DueDate:   i.DueDate,
+		Assignee:  i.Assignee,
+		State:     i.State,
+		TeamName:  linear.TeamName{Key: i.TeamKey},
+	}
+	for _, t := range i.History {
+		issue.IssueHistory.Nodes = append(issue.IssueHistory.Nodes, linear.IssueHistoryNode{CreatedAt: t.At, FromState: t.From, ToState: t.To})
+	}
+	if !i.LastCommentAt.IsZero() {
+		issue.IssueComments.Nodes = append(issue.IssueComments.Nodes, linear.IssueCommentNode{CreatedAt: i.LastCommentAt})
+	}
+	for _, l := range i.Labels {
+		issue.IssueLabels.Nodes = append(issue.IssueLabels.Nodes, linear.IssueLabelNode{ID: l.ID, Name: l.Name})
+	}
+	for _, id := range i.SubscriberIDs {
+		issue.Subscribers.Nodes = append(issue.Subscribers.Nodes, linear.UserNode{ID: id})
+	}
+
+	return issue
+}